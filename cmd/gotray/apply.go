@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/example/gotray/internal/config"
+)
+
+// operation is one entry of an apply batch: "op" names the command, "ref"
+// names the ID created by an add, and all other keys are that command's
+// flags.
+type operation map[string]any
+
+// applyHandlers are the commands an apply batch may run.
+var applyHandlers = map[string]func(*config.Config, []string, commandEnv) (commandResult, error){
+	"add":    handleAdd,
+	"update": handleUpdate,
+	"delete": handleDelete,
+	"move":   handleMove,
+}
+
+func handleApply(cfg *config.Config, args []string) error {
+	fs := newFlagSet("apply")
+	var path string
+	fs.StringVar(&path, "f", "", "JSON file with the operations to apply (- for stdin)")
+	fs.StringVar(&path, "file", "", "alias for -f")
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch path {
+	case "":
+		return errors.New("specify -f with the operations file, or -f - to read from stdin")
+	case "-":
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("read operations: %w", err)
+	}
+
+	ops, err := parseOperations(data)
+	if err != nil {
+		return err
+	}
+	results, err := applyOperations(cfg, ops)
+	if err != nil {
+		return fmt.Errorf("%w; no changes were saved", err)
+	}
+	if err := saveConfig(cfg, format); err != nil {
+		return err
+	}
+	return writeApplyResults(os.Stdout, format, results)
+}
+
+// parseOperations decodes a JSON array of operations and checks their
+// commands and references before anything runs.
+func parseOperations(data []byte) ([]operation, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var ops []operation
+	if err := decoder.Decode(&ops); err != nil {
+		return nil, fmt.Errorf("parse operations: %w", err)
+	}
+	if len(ops) == 0 {
+		return nil, errors.New("no operations to apply")
+	}
+
+	refs := make(map[string]bool)
+	for idx, op := range ops {
+		name, _ := op["op"].(string)
+		if _, ok := applyHandlers[name]; !ok {
+			return nil, fmt.Errorf("operation %d: unknown op %q (use add, update, delete, or move)", idx+1, op["op"])
+		}
+		if _, ok := op["output"]; ok {
+			return nil, fmt.Errorf("operation %d: output cannot be set per operation", idx+1)
+		}
+		if raw, ok := op["ref"]; ok {
+			ref, _ := raw.(string)
+			switch {
+			case name != "add":
+				return nil, fmt.Errorf("operation %d: ref is only supported by add", idx+1)
+			case ref == "":
+				return nil, fmt.Errorf("operation %d: ref must be a non-empty string", idx+1)
+			case refs[ref]:
+				return nil, fmt.Errorf("operation %d: ref %q is already defined", idx+1, ref)
+			}
+			refs[ref] = true
+		}
+	}
+	return ops, nil
+}
+
+// operationError reports the operation of a batch that failed, by its
+// 0-based index, together with the error of its command.
+type operationError struct {
+	index int
+	op    string
+	err   error
+}
+
+func (e *operationError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %v", e.index+1, e.op, e.err)
+}
+
+func (e *operationError) Unwrap() error {
+	return e.err
+}
+
+// applyOperations runs ops against cfg in memory. The first failing
+// operation aborts the batch; cfg is then left partially changed and must
+// not be saved. Its error is an *operationError.
+func applyOperations(cfg *config.Config, ops []operation) ([]commandResult, error) {
+	env := commandEnv{
+		out:  io.Discard,
+		save: func(*config.Config, outputFormat) error { return nil },
+	}
+
+	refs := make(map[string]string)
+	results := make([]commandResult, 0, len(ops))
+	for idx, op := range ops {
+		name := op["op"].(string)
+		args, err := op.arguments(refs)
+		if err != nil {
+			return nil, &operationError{index: idx, op: name, err: err}
+		}
+
+		result, err := applyHandlers[name](cfg, args, env)
+		if err != nil {
+			return nil, &operationError{index: idx, op: name, err: err}
+		}
+		if ref, ok := op["ref"].(string); ok {
+			refs[ref] = result.ID
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// arguments converts the operation into command-line flags. "$name" in id
+// and parent refers to the ID created by the add with that ref; args and
+// steps are passed on as JSON arrays, header may be an object of names and
+// values, and other lists repeat the flag.
+func (op operation) arguments(refs map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(op))
+	for key := range op {
+		if key != "op" && key != "ref" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, key := range keys {
+		flagName := "--" + key
+		switch value := op[key].(type) {
+		case nil:
+			args = append(args, flagName+"=")
+		case string:
+			if (key == "id" || key == "parent") && strings.HasPrefix(value, "$") {
+				id, ok := refs[value[1:]]
+				if !ok {
+					return nil, fmt.Errorf("%s refers to unknown ref %q", key, value[1:])
+				}
+				value = id
+			}
+			args = append(args, flagName+"="+value)
+		case json.Number:
+			args = append(args, flagName+"="+value.String())
+		case bool:
+			args = append(args, fmt.Sprintf("%s=%t", flagName, value))
+		case []any:
+			switch key {
+			case "steps":
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, flagName+"="+string(encoded))
+			case "args":
+				// A JSON array keeps arguments that contain commas intact.
+				parts := make([]string, 0, len(value))
+				for _, part := range value {
+					parts = append(parts, fmt.Sprint(part))
+				}
+				encoded, err := json.Marshal(parts)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, flagName+"="+string(encoded))
+			default:
+				for _, part := range value {
+					args = append(args, flagName+"="+fmt.Sprint(part))
+				}
+			}
+		case map[string]any:
+			if key != "header" {
+				return nil, fmt.Errorf("unsupported value for %s", key)
+			}
+			names := make([]string, 0, len(value))
+			for name := range value {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				args = append(args, fmt.Sprintf("%s=%s: %v", flagName, name, value[name]))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported value for %s", key)
+		}
+	}
+	return args, nil
+}
@@ -30,7 +30,9 @@ func shouldShowConsole(args []string) bool {
 
 		normalized := strings.ToLower(strings.TrimLeft(trimmed, "-/"))
 		switch {
-		case normalized == "console":
+		// The terminal interface draws into the console it was started
+		// from, and terminal editors such as vim run inside it.
+		case normalized == "console" || normalized == "tui" || normalized == "edit":
 			return true
 		case strings.HasPrefix(normalized, "console="):
 			value := strings.TrimPrefix(normalized, "console=")
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/menu"
+)
+
+func handleEdit(cfg *config.Config, args []string) error {
+	fs := newFlagSet("edit")
+	formatFlag := fs.String("format", "json", "format of the edited file: json or yaml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	format := outputFormat(strings.ToLower(strings.TrimSpace(*formatFlag)))
+	if format != outputJSON && format != outputYAML {
+		return fmt.Errorf("unknown edit format %q (use json or yaml)", *formatFlag)
+	}
+	editor, err := editorCommand()
+	if err != nil {
+		return err
+	}
+
+	menu.EnsureSequentialOrder(&cfg.Items)
+	var buf bytes.Buffer
+	if format == outputJSON {
+		err = writeJSON(&buf, cfg)
+	} else {
+		err = writeYAML(&buf, cfg)
+	}
+	if err != nil {
+		return err
+	}
+	original := buf.String()
+
+	// The file holds commands and possibly credentials, so it lives in a
+	// directory only the current user can read.
+	dir, err := os.MkdirTemp("", "gotray-edit-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config."+string(format))
+
+	content := original
+	var lastErr error
+	for {
+		if err := os.WriteFile(path, []byte(editHeader(format, lastErr)+content), 0o600); err != nil {
+			return err
+		}
+		if err := runEditor(editor, path); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		body := stripEditHeader(string(edited), format)
+		switch {
+		case strings.TrimSpace(body) == strings.TrimSpace(original):
+			fmt.Println("Edit cancelled, no changes made")
+			return nil
+		case lastErr != nil && body == content:
+			return fmt.Errorf("edit cancelled: %w", lastErr)
+		}
+		content = body
+
+		updated, err := decodeEdited(body, format)
+		if err == nil {
+			err = validateEdited(updated)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		stampEditedItems(cfg.Items, updated.Items, time.Now().UTC().Format(time.RFC3339))
+		cfg.Items = updated.Items
+		cfg.Settings = updated.Settings
+		menu.EnsureSequentialOrder(&cfg.Items)
+		if err := saveConfig(cfg, outputTable); err != nil {
+			return err
+		}
+		fmt.Println("Updated configuration")
+		return nil
+	}
+}
+
+// stampEditedItems sets UpdatedUTC on the edited items that differ from
+// their original, like the other commands that change an item. Order values
+// are ignored, since moving one item renumbers its siblings.
+func stampEditedItems(original, edited []config.MenuItem, now string) {
+	before := make(map[string]config.MenuItem, len(original))
+	for _, item := range original {
+		before[item.ID] = item
+	}
+	for idx, item := range edited {
+		previous, ok := before[item.ID]
+		if !ok {
+			continue
+		}
+		// Compare the saved form, in which empty and missing lists match.
+		previous.Order, previous.UpdatedUTC = item.Order, item.UpdatedUTC
+		was, errWas := json.Marshal(previous)
+		is, errIs := json.Marshal(item)
+		if errWas != nil || errIs != nil || !bytes.Equal(was, is) {
+			edited[idx].UpdatedUTC = now
+		}
+	}
+}
+
+// editorCommand returns the user's editor from VISUAL or EDITOR, split into
+// the program and its arguments, such as "code --wait".
+func editorCommand() ([]string, error) {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields, nil
+		}
+	}
+	fallback := "vi"
+	if runtime.GOOS == "windows" {
+		fallback = "notepad"
+	}
+	if _, err := exec.LookPath(fallback); err != nil {
+		return nil, errors.New("no editor found; set VISUAL or EDITOR")
+	}
+	return []string{fallback}, nil
+}
+
+func runEditor(editor []string, path string) error {
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %s: %w", editor[0], err)
+	}
+	return nil
+}
+
+// editComment is the line comment marker of the edited file. JSON has no
+// comments, so the leading "//" lines are removed before parsing.
+func editComment(format outputFormat) string {
+	if format == outputYAML {
+		return "#"
+	}
+	return "//"
+}
+
+// editHeader explains the edit session and reports the error of the
+// previous attempt.
+func editHeader(format outputFormat, lastErr error) string {
+	marker := editComment(format)
+	lines := []string{
+		"Edit the GoTray configuration and close the editor to save it.",
+		"Leave it unchanged to cancel. These comment lines are ignored.",
+	}
+	if lastErr != nil {
+		lines = append(lines, "", "The configuration was not saved:")
+		for _, line := range strings.Split(lastErr.Error(), "\n") {
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(marker+" "+line, " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// stripEditHeader removes the leading comment block. Only leading lines are
+// removed so comment markers inside YAML block scalars survive.
+func stripEditHeader(text string, format outputFormat) string {
+	marker := editComment(format)
+	for text != "" {
+		line, rest, _ := strings.Cut(text, "\n")
+		if !strings.HasPrefix(strings.TrimSpace(line), marker) {
+			break
+		}
+		text = rest
+	}
+	return text
+}
+
+// decodeEdited parses the edited file, rejecting unknown fields so typos do
+// not silently drop settings.
+func decodeEdited(body string, format outputFormat) (*config.Config, error) {
+	data := []byte(body)
+	if format == outputYAML {
+		var value any
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("parse YAML: %w", err)
+		}
+		converted, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse YAML: %w", err)
+		}
+		data = converted
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var cfg config.Config
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parse configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
+// validateEdited applies the checks of import to the menu items and those
+// of the settings command to the settings, which it normalizes the same way.
+func validateEdited(cfg *config.Config) error {
+	if err := prepareImportedItems(cfg.Items, ""); err != nil {
+		return err
+	}
+	if err := validateParents(cfg.Items); err != nil {
+		return err
+	}
+	return normalizeSettings(&cfg.Settings)
+}
@@ -7,17 +7,26 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"os/user"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/diff"
+	"github.com/example/gotray/internal/locale"
 	"github.com/example/gotray/internal/logging"
 	"github.com/example/gotray/internal/menu"
+	"github.com/example/gotray/internal/runlog"
+	"github.com/example/gotray/internal/theme"
 	"github.com/example/gotray/internal/trmm"
 )
 
@@ -26,13 +35,16 @@ func main() {
 
 	args := os.Args[1:]
 	var err error
-	args, debug, offline, importTRMM, err := parseGlobalFlags(args)
+	args, debug, offline, importTRMM, dryRun, err := parseGlobalFlags(args)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
 	if debug {
 		logging.EnableDebug()
 	}
+	if dryRun {
+		saveConfig = previewConfig
+	}
 
 	if len(args) == 0 && importTRMM {
 		if err := importFromTacticalRMM(); err != nil {
@@ -65,7 +77,7 @@ func main() {
 	}
 
 	if implicitMode {
-		log.Fatalf("unknown run mode %q; specify run, add, update, delete, list, move, export, or import", args[0])
+		log.Fatalf("unknown run mode %q; specify run, add, update, delete, apply, edit, tui, list, tree, show, diff, move, export, import, runs, or settings", args[0])
 	}
 
 	if importTRMM {
@@ -78,7 +90,11 @@ func main() {
 		log.Fatalf("failed to load configuration: %v", err)
 	}
 
-	if err := handleCLI(cfg, args); err != nil {
+	if err := handleCLI(cfg, args, offline); err != nil {
+		var exit exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		log.Fatalf("%v", err)
 	}
 }
@@ -97,7 +113,7 @@ func runStandalone(offline bool) error {
 	return nil
 }
 
-func handleCLI(cfg *config.Config, args []string) error {
+func handleCLI(cfg *config.Config, args []string, offline bool) error {
 	if len(args) == 0 {
 		return errors.New("no command provided")
 	}
@@ -107,19 +123,39 @@ func handleCLI(cfg *config.Config, args []string) error {
 	command := normalizeCommand(args[0])
 	switch command {
 	case "add":
-		return handleAdd(cfg, args[1:])
+		_, err := handleAdd(cfg, args[1:], cliEnv())
+		return err
 	case "update":
-		return handleUpdate(cfg, args[1:])
+		_, err := handleUpdate(cfg, args[1:], cliEnv())
+		return err
 	case "delete":
-		return handleDelete(cfg, args[1:])
+		_, err := handleDelete(cfg, args[1:], cliEnv())
+		return err
 	case "list":
-		return handleList(cfg)
+		return handleList(cfg, args[1:])
+	case "tree":
+		return handleTree(cfg, args[1:], offline)
+	case "show":
+		return handleShow(cfg, args[1:])
+	case "diff":
+		return handleDiff(cfg, args[1:])
 	case "move":
-		return handleMove(cfg, args[1:])
+		_, err := handleMove(cfg, args[1:], cliEnv())
+		return err
 	case "export":
-		return handleExport(cfg)
+		return handleExport(cfg, args[1:])
 	case "import":
 		return handleImport(cfg, args[1:])
+	case "apply":
+		return handleApply(cfg, args[1:])
+	case "edit":
+		return handleEdit(cfg, args[1:])
+	case "tui":
+		return handleTUI(cfg, args[1:])
+	case "runs":
+		return handleRuns(args[1:])
+	case "settings":
+		return handleSettings(cfg, args[1:])
 	default:
 		return fmt.Errorf("unknown command: %s", args[0])
 	}
@@ -130,18 +166,27 @@ func normalizeCommand(arg string) string {
 	return strings.ToLower(trimmed)
 }
 
-func parseGlobalFlags(args []string) ([]string, bool, bool, bool, error) {
+func parseGlobalFlags(args []string) ([]string, bool, bool, bool, bool, error) {
 	debugEnabled := false
 	offlineEnabled := false
 	importTRMM := false
+	dryRun := false
 	filtered := make([]string, 0, len(args))
 
 	skipNext := false
+	valueNext := false
 	for idx := 0; idx < len(args); idx++ {
 		if skipNext {
 			skipNext = false
 			continue
 		}
+		if valueNext {
+			// The value of --state-icon, such as offline=/path/icon.png,
+			// must not be read as the global offline flag.
+			valueNext = false
+			filtered = append(filtered, args[idx])
+			continue
+		}
 
 		arg := args[idx]
 		if skip, consumeNext := shouldIgnoreBuildFlag(arg); skip {
@@ -154,6 +199,8 @@ func parseGlobalFlags(args []string) ([]string, bool, bool, bool, error) {
 		normalized := strings.TrimSpace(arg)
 		lower := strings.ToLower(strings.TrimLeft(normalized, "-/"))
 		switch {
+		case lower == "state-icon" && len(lower) < len(normalized):
+			valueNext = true
 		case lower == "debug":
 			debugEnabled = true
 			continue
@@ -161,7 +208,7 @@ func parseGlobalFlags(args []string) ([]string, bool, bool, bool, error) {
 			value := strings.TrimPrefix(lower, "debug=")
 			parsed, err := strconv.ParseBool(value)
 			if err != nil {
-				return nil, false, false, false, fmt.Errorf("invalid value for --debug: %s", arg)
+				return nil, false, false, false, false, fmt.Errorf("invalid value for --debug: %s", arg)
 			}
 			debugEnabled = parsed
 			continue
@@ -172,7 +219,7 @@ func parseGlobalFlags(args []string) ([]string, bool, bool, bool, error) {
 			value := strings.TrimPrefix(lower, "offline=")
 			parsed, err := strconv.ParseBool(value)
 			if err != nil {
-				return nil, false, false, false, fmt.Errorf("invalid value for --offline: %s", arg)
+				return nil, false, false, false, false, fmt.Errorf("invalid value for --offline: %s", arg)
 			}
 			offlineEnabled = parsed
 			continue
@@ -183,22 +230,87 @@ func parseGlobalFlags(args []string) ([]string, bool, bool, bool, error) {
 			value := strings.TrimPrefix(lower, "importtrmm=")
 			parsed, err := strconv.ParseBool(value)
 			if err != nil {
-				return nil, false, false, false, fmt.Errorf("invalid value for --importtrmm: %s", arg)
+				return nil, false, false, false, false, fmt.Errorf("invalid value for --importtrmm: %s", arg)
 			}
 			importTRMM = parsed
 			continue
+		case lower == "dry-run" || lower == "dryrun":
+			dryRun = true
+			continue
+		case strings.HasPrefix(lower, "dry-run=") || strings.HasPrefix(lower, "dryrun="):
+			_, value, _ := strings.Cut(lower, "=")
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, false, false, false, false, fmt.Errorf("invalid value for --dry-run: %s", arg)
+			}
+			dryRun = parsed
+			continue
 		case lower == "console":
 			continue
 		case strings.HasPrefix(lower, "console="):
 			if _, err := strconv.ParseBool(strings.TrimPrefix(lower, "console=")); err != nil {
-				return nil, false, false, false, fmt.Errorf("invalid value for --console: %s", arg)
+				return nil, false, false, false, false, fmt.Errorf("invalid value for --console: %s", arg)
 			}
 			continue
 		}
 		filtered = append(filtered, arg)
 	}
 
-	return filtered, debugEnabled, offlineEnabled, importTRMM, nil
+	return filtered, debugEnabled, offlineEnabled, importTRMM, dryRun, nil
+}
+
+// saveFunc stores the configuration changed by a command whose result is
+// printed in format.
+type saveFunc func(cfg *config.Config, format outputFormat) error
+
+// saveConfig persists the configuration. The global --dry-run flag replaces
+// it with previewConfig.
+var saveConfig saveFunc = func(cfg *config.Config, _ outputFormat) error {
+	return config.Save(cfg)
+}
+
+// commandEnv is where a command that changes menu items prints its result
+// and how it saves the configuration. apply runs the commands with an env
+// that neither prints nor saves, and collects the returned results.
+type commandEnv struct {
+	out  io.Writer
+	save saveFunc
+}
+
+// cliEnv prints to stdout and saves with saveConfig.
+func cliEnv() commandEnv {
+	return commandEnv{out: os.Stdout, save: saveConfig}
+}
+
+// previewConfig prints a unified diff between the saved configuration and
+// cfg instead of writing it. The diff goes to stderr when the command prints
+// a machine-readable result, so stdout stays parseable.
+func previewConfig(cfg *config.Config, format outputFormat) error {
+	current, err := config.Load()
+	if err != nil {
+		return err
+	}
+	before, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal configuration: %w", err)
+	}
+	after, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal configuration: %w", err)
+	}
+
+	changes := diff.Unified("saved configuration", "dry run", string(before)+"\n", string(after)+"\n")
+	if changes == "" {
+		fmt.Fprintln(os.Stderr, "Dry run: no changes")
+		return nil
+	}
+	out := io.Writer(os.Stdout)
+	if format != outputTable {
+		out = os.Stderr
+	}
+	fmt.Fprint(out, changes)
+	fmt.Fprintln(os.Stderr, "Dry run: configuration not saved")
+	return nil
 }
 
 func shouldIgnoreBuildFlag(arg string) (skip bool, consumeNext bool) {
@@ -282,7 +394,7 @@ func importFromTacticalRMM() error {
 			item.UpdatedUTC = item.CreatedUTC
 		}
 
-		if err := validateItem(item); err != nil {
+		if err := menu.ValidateItem(item); err != nil {
 			return fmt.Errorf("item %s invalid: %w", item.ID, err)
 		}
 
@@ -298,7 +410,7 @@ func importFromTacticalRMM() error {
 	menu.EnsureSequentialOrder(&items)
 
 	cfg.Items = items
-	if err := config.Save(cfg); err != nil {
+	if err := saveConfig(cfg, outputTable); err != nil {
 		return fmt.Errorf("save configuration: %w", err)
 	}
 
@@ -306,45 +418,99 @@ func importFromTacticalRMM() error {
 	return nil
 }
 
-func handleAdd(cfg *config.Config, args []string) error {
+func handleAdd(cfg *config.Config, args []string, env commandEnv) (commandResult, error) {
 	fs := newFlagSet("add")
-	itemType := fs.String("type", string(config.MenuItemText), "menu item type: text, divider, command, url, menu, refresh, quit")
+	fs.SetOutput(env.out)
+	itemType := fs.String("type", string(config.MenuItemText), "menu item type: text, divider, command, workflow, url, http, wol, menu, generator, refresh, results, tasks, quit")
 	label := fs.String("label", "", "display label")
 	command := fs.String("command", "", "command or executable path")
-	argList := fs.String("args", "", "comma-separated command arguments")
+	argList := fs.String("args", "", "comma-separated command arguments, or a JSON array of strings")
 	workDir := fs.String("workdir", "", "working directory for command execution")
 	url := fs.String("url", "", "target URL")
+	method := fs.String("method", "", "HTTP method for http items (defaults to POST with a body, otherwise GET)")
+	headers := headerFlag{}
+	fs.Var(headers, "header", "HTTP header for http items as \"Name: value\" (repeatable)")
+	body := fs.String("body", "", "request body template for http items")
+	mac := fs.String("mac", "", "MAC address woken by wol items")
+	broadcast := fs.String("broadcast", "", "broadcast address for wol items (defaults to 255.255.255.255)")
+	port := fs.Int("port", 0, "UDP port for wol items (defaults to 9)")
+	source := fs.String("source", "", "JSON file providing the children of a generator item")
+	interval := fs.Int("interval", 0, "seconds between generator re-evaluations in addition to every refresh (0 disables)")
 	description := fs.String("description", "", "tooltip description")
+	icon := fs.String("icon", "", "image file shown next to the item label")
+	directory := fs.String("directory", "", "directory of scripts listed by a menu item")
 	position := fs.Int("position", 0, "1-based position where the item should be inserted; defaults to the end")
 	parent := fs.String("parent", "", "parent menu id for nested items")
-
+	timeout := fs.Int("timeout", 0, "seconds after which a running command or http request is stopped (0 uses the default)")
+	singleInstance := fs.Bool("single-instance", false, "do not relaunch a command while it is still running")
+	detach := fs.Bool("detach", false, "keep the command running after GoTray quits")
+	terminal := fs.Bool("terminal", false, "run the command inside a terminal window")
+	keepOpen := fs.Bool("keep-open", false, "keep the terminal window open after the command exits")
+	steps := fs.String("steps", "", "JSON array of workflow steps ({\"command\", \"arguments\", \"workingDir\", \"continueOnError\"})")
+	output := outputFlag(fs)
+
+	args, labels, descriptions, err := extractLocalizedFlags(args)
+	if err != nil {
+		return commandResult{}, err
+	}
 	if err := fs.Parse(args); err != nil {
-		return err
+		return commandResult{}, err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return commandResult{}, err
+	}
+
+	workflowSteps, err := parseSteps(*steps)
+	if err != nil {
+		return commandResult{}, err
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
 	normalizedType := config.MenuItemType(strings.ToLower(*itemType))
 	parentID := strings.TrimSpace(*parent)
+	arguments, err := parseArguments(*argList)
+	if err != nil {
+		return commandResult{}, err
+	}
 	item := config.MenuItem{
-		ID:          menu.GenerateID(cfg.Items, parentID, normalizedType),
-		Type:        normalizedType,
-		Label:       *label,
-		Command:     *command,
-		Arguments:   parseList(*argList),
-		WorkingDir:  *workDir,
-		URL:         *url,
-		Description: *description,
-		ParentID:    parentID,
-		CreatedUTC:  now,
-		UpdatedUTC:  now,
-	}
-
-	if err := validateItem(item); err != nil {
-		return err
+		ID:              menu.GenerateID(cfg.Items, parentID, normalizedType),
+		Type:            normalizedType,
+		Label:           *label,
+		Command:         *command,
+		Arguments:       arguments,
+		WorkingDir:      *workDir,
+		URL:             *url,
+		Method:          strings.ToUpper(strings.TrimSpace(*method)),
+		Headers:         headers.value(),
+		Body:            *body,
+		MAC:             strings.TrimSpace(*mac),
+		Broadcast:       strings.TrimSpace(*broadcast),
+		Port:            *port,
+		Source:          strings.TrimSpace(*source),
+		IntervalSeconds: *interval,
+		Description:     *description,
+		ParentID:        parentID,
+		Labels:          withoutEmpty(labels),
+		Descriptions:    withoutEmpty(descriptions),
+		Icon:            strings.TrimSpace(*icon),
+		Directory:       strings.TrimSpace(*directory),
+		TimeoutSeconds:  *timeout,
+		SingleInstance:  *singleInstance,
+		Detach:          *detach,
+		Terminal:        *terminal,
+		KeepOpen:        *keepOpen,
+		Steps:           workflowSteps,
+		CreatedUTC:      now,
+		UpdatedUTC:      now,
+	}
+
+	if err := menu.ValidateItem(item); err != nil {
+		return commandResult{}, err
 	}
 
 	if err := menu.ValidateParent(cfg.Items, item); err != nil {
-		return err
+		return commandResult{}, err
 	}
 
 	idx := len(cfg.Items)
@@ -360,37 +526,66 @@ func handleAdd(cfg *config.Config, args []string) error {
 
 	cfg.Items = menu.InsertItem(cfg.Items, idx, item)
 	menu.EnsureSequentialOrder(&cfg.Items)
-	if err := config.Save(cfg); err != nil {
-		return err
+	if err := env.save(cfg, format); err != nil {
+		return commandResult{}, err
 	}
 
-	fmt.Printf("Added menu item %s of type %s at position %d\n", item.ID, item.Type, idx+1)
-	return nil
+	message := fmt.Sprintf("Added menu item %s of type %s at position %d", item.ID, item.Type, idx+1)
+	result := itemResult("added", item, idx+1)
+	return result, writeResult(env.out, format, result, message)
 }
 
-func handleUpdate(cfg *config.Config, args []string) error {
+func handleUpdate(cfg *config.Config, args []string, env commandEnv) (commandResult, error) {
 	fs := newFlagSet("update")
+	fs.SetOutput(env.out)
 	id := fs.String("id", "", "identifier of the menu item to update")
 	itemType := fs.String("type", "", "new item type")
 	label := fs.String("label", "", "display label")
 	command := fs.String("command", "", "command or executable path")
-	argList := fs.String("args", "", "comma-separated command arguments")
+	argList := fs.String("args", "", "comma-separated command arguments, or a JSON array of strings")
 	workDir := fs.String("workdir", "", "working directory")
 	url := fs.String("url", "", "target URL")
+	method := fs.String("method", "", "HTTP method for http items")
+	headers := headerFlag{}
+	fs.Var(headers, "header", "HTTP header for http items as \"Name: value\" (repeatable; replaces existing headers)")
+	body := fs.String("body", "", "request body template for http items")
+	mac := fs.String("mac", "", "MAC address woken by wol items")
+	broadcast := fs.String("broadcast", "", "broadcast address for wol items")
+	port := fs.Int("port", 0, "UDP port for wol items")
+	source := fs.String("source", "", "JSON file providing the children of a generator item")
+	interval := fs.Int("interval", 0, "seconds between generator re-evaluations (0 disables)")
 	description := fs.String("description", "", "tooltip description")
+	icon := fs.String("icon", "", "image file shown next to the item label (empty string removes it)")
+	directory := fs.String("directory", "", "directory of scripts listed by a menu item (empty string removes it)")
 	parent := fs.String("parent", "__unchanged__", "parent menu id (empty string for top level)")
-
+	timeout := fs.Int("timeout", 0, "seconds after which a running command or http request is stopped (0 uses the default)")
+	singleInstance := fs.Bool("single-instance", false, "do not relaunch a command while it is still running")
+	detach := fs.Bool("detach", false, "keep the command running after GoTray quits")
+	terminal := fs.Bool("terminal", false, "run the command inside a terminal window")
+	keepOpen := fs.Bool("keep-open", false, "keep the terminal window open after the command exits")
+	steps := fs.String("steps", "", "JSON array of workflow steps")
+	output := outputFlag(fs)
+
+	args, labels, descriptions, err := extractLocalizedFlags(args)
+	if err != nil {
+		return commandResult{}, err
+	}
 	if err := fs.Parse(args); err != nil {
-		return err
+		return commandResult{}, err
+	}
+	provided := providedFlags(fs)
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return commandResult{}, err
 	}
 
 	if *id == "" {
-		return errors.New("missing --id for update")
+		return commandResult{}, errors.New("missing --id for update")
 	}
 
 	idx := findItemIndexByID(cfg.Items, *id)
 	if idx == -1 {
-		return fmt.Errorf("item with id %s not found", *id)
+		return commandResult{}, fmt.Errorf("item with id %s not found", *id)
 	}
 
 	item := cfg.Items[idx]
@@ -400,16 +595,20 @@ func handleUpdate(cfg *config.Config, args []string) error {
 	if *label != "" {
 		item.Label = *label
 	}
-	if *command != "" || (*itemType != "" && item.Type != config.MenuItemCommand) {
+	if *command != "" || (*itemType != "" && !runsCommand(item.Type)) {
 		item.Command = *command
 	}
-	if *argList != "" || (*itemType != "" && item.Type != config.MenuItemCommand) {
-		item.Arguments = parseList(*argList)
+	if *argList != "" || (*itemType != "" && !runsCommand(item.Type)) {
+		arguments, err := parseArguments(*argList)
+		if err != nil {
+			return commandResult{}, err
+		}
+		item.Arguments = arguments
 	}
-	if *workDir != "" || (*itemType != "" && item.Type != config.MenuItemCommand) {
+	if *workDir != "" || (*itemType != "" && !runsCommand(item.Type)) {
 		item.WorkingDir = *workDir
 	}
-	if *url != "" || (*itemType != "" && item.Type != config.MenuItemURL) {
+	if *url != "" || (*itemType != "" && item.Type != config.MenuItemURL && item.Type != config.MenuItemHTTP) {
 		item.URL = *url
 	}
 	if *description != "" {
@@ -418,57 +617,151 @@ func handleUpdate(cfg *config.Config, args []string) error {
 	if parent != nil && *parent != "__unchanged__" {
 		item.ParentID = strings.TrimSpace(*parent)
 	}
+	if provided["timeout"] {
+		item.TimeoutSeconds = *timeout
+	}
+	if provided["single-instance"] {
+		item.SingleInstance = *singleInstance
+	}
+	if provided["detach"] {
+		item.Detach = *detach
+	}
+	if provided["terminal"] {
+		item.Terminal = *terminal
+	}
+	if provided["keep-open"] {
+		item.KeepOpen = *keepOpen
+	}
+	if provided["method"] {
+		item.Method = strings.ToUpper(strings.TrimSpace(*method))
+	}
+	if provided["header"] {
+		item.Headers = headers.value()
+	}
+	if provided["body"] {
+		item.Body = *body
+	}
+	if provided["mac"] {
+		item.MAC = strings.TrimSpace(*mac)
+	}
+	if provided["broadcast"] {
+		item.Broadcast = strings.TrimSpace(*broadcast)
+	}
+	if provided["port"] {
+		item.Port = *port
+	}
+	item.Labels = mergeTranslations(item.Labels, labels)
+	item.Descriptions = mergeTranslations(item.Descriptions, descriptions)
+	if provided["icon"] {
+		item.Icon = strings.TrimSpace(*icon)
+	}
+	if provided["directory"] {
+		item.Directory = strings.TrimSpace(*directory)
+	}
+	if provided["source"] {
+		item.Source = strings.TrimSpace(*source)
+	}
+	if provided["interval"] {
+		item.IntervalSeconds = *interval
+	}
+	if provided["steps"] {
+		parsed, err := parseSteps(*steps)
+		if err != nil {
+			return commandResult{}, err
+		}
+		item.Steps = parsed
+	}
+	if *itemType != "" && item.Type != config.MenuItemCommand {
+		item.Terminal = false
+		item.KeepOpen = false
+		if item.Type != config.MenuItemWorkflow {
+			item.SingleInstance = false
+			item.Detach = false
+			if item.Type != config.MenuItemHTTP && item.Type != config.MenuItemGenerator {
+				item.TimeoutSeconds = 0
+			}
+		}
+	}
+	if *itemType != "" && item.Type != config.MenuItemHTTP {
+		item.Method = ""
+		item.Headers = nil
+		item.Body = ""
+	}
+	if *itemType != "" && item.Type != config.MenuItemMenu {
+		item.Directory = ""
+	}
+	if *itemType != "" && item.Type != config.MenuItemGenerator {
+		item.Source = ""
+		item.IntervalSeconds = 0
+	}
+	if *itemType != "" && item.Type != config.MenuItemWOL {
+		item.MAC = ""
+		item.Broadcast = ""
+		item.Port = 0
+	}
+	if *itemType != "" && item.Type != config.MenuItemWorkflow {
+		item.Steps = nil
+	}
 	item.UpdatedUTC = time.Now().UTC().Format(time.RFC3339)
 
-	if err := validateItem(item); err != nil {
-		return err
+	if err := menu.ValidateItem(item); err != nil {
+		return commandResult{}, err
 	}
 
 	if err := menu.ValidateParent(cfg.Items, item); err != nil {
-		return err
+		return commandResult{}, err
 	}
 
 	cfg.Items[idx] = item
 	menu.EnsureSequentialOrder(&cfg.Items)
-	if err := config.Save(cfg); err != nil {
-		return err
+	if err := env.save(cfg, format); err != nil {
+		return commandResult{}, err
 	}
 
-	fmt.Printf("Updated menu item %s\n", item.ID)
-	return nil
+	message := fmt.Sprintf("Updated menu item %s", item.ID)
+	result := itemResult("updated", item, idx+1)
+	return result, writeResult(env.out, format, result, message)
 }
 
-func handleDelete(cfg *config.Config, args []string) error {
+func handleDelete(cfg *config.Config, args []string, env commandEnv) (commandResult, error) {
 	fs := newFlagSet("delete")
+	fs.SetOutput(env.out)
 	id := fs.String("id", "", "identifier of the menu item to delete")
 	label := fs.String("label", "", "label of the menu item to delete")
 	deleteAll := fs.Bool("all", false, "remove all menu items")
+	recursive := fs.Bool("recursive", false, "also delete the items nested below it")
+	output := outputFlag(fs)
 	if err := fs.Parse(args); err != nil {
-		return err
+		return commandResult{}, err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return commandResult{}, err
 	}
 
 	if *deleteAll {
 		if *id != "" || *label != "" {
-			return errors.New("--all cannot be combined with --id or --label")
+			return commandResult{}, errors.New("--all cannot be combined with --id or --label")
 		}
 
 		count := len(cfg.Items)
 		if count == 0 {
-			fmt.Println("No menu items to delete")
-			return nil
+			result := commandResult{Action: "deleted"}
+			return result, writeResult(env.out, format, result, "No menu items to delete")
 		}
 
 		cfg.Items = nil
-		if err := config.Save(cfg); err != nil {
-			return err
+		if err := env.save(cfg, format); err != nil {
+			return commandResult{}, err
 		}
 
-		fmt.Printf("Deleted all %d menu items\n", count)
-		return nil
+		message := fmt.Sprintf("Deleted all %d menu items", count)
+		result := commandResult{Action: "deleted", Count: count}
+		return result, writeResult(env.out, format, result, message)
 	}
 
 	if *id == "" && *label == "" {
-		return errors.New("specify --id or --label for delete")
+		return commandResult{}, errors.New("specify --id or --label for delete")
 	}
 
 	descriptor := ""
@@ -482,38 +775,53 @@ func handleDelete(cfg *config.Config, args []string) error {
 		descriptor = fmt.Sprintf("label %q", *label)
 	}
 	if idx == -1 {
-		return fmt.Errorf("item with %s not found", descriptor)
+		return commandResult{}, fmt.Errorf("item with %s not found", descriptor)
 	}
 
-	removed := cfg.Items[idx]
-	cfg.Items = menu.RemoveIndex(cfg.Items, idx)
+	subtree := []config.MenuItem{cfg.Items[idx]}
+	if *recursive {
+		cfg.Items, subtree = menu.RemoveSubtree(cfg.Items, cfg.Items[idx].ID)
+	} else {
+		cfg.Items = menu.RemoveIndex(cfg.Items, idx)
+	}
+	removed := subtree[0]
 	menu.EnsureSequentialOrder(&cfg.Items)
-	if err := config.Save(cfg); err != nil {
-		return err
+	if err := env.save(cfg, format); err != nil {
+		return commandResult{}, err
 	}
 
-	if *id != "" {
-		fmt.Printf("Deleted menu item %s\n", removed.ID)
-	} else {
-		fmt.Printf("Deleted menu item %s with label %q\n", removed.ID, removed.Label)
+	message := fmt.Sprintf("Deleted menu item %s", removed.ID)
+	if *id == "" {
+		message = fmt.Sprintf("Deleted menu item %s with label %q", removed.ID, removed.Label)
 	}
-	return nil
+	if nested := len(subtree) - 1; nested > 0 {
+		message += fmt.Sprintf(" and %d nested items", nested)
+	}
+	result := itemResult("deleted", removed, idx+1)
+	result.Count = len(subtree)
+	return result, writeResult(env.out, format, result, message)
 }
 
-func handleMove(cfg *config.Config, args []string) error {
+func handleMove(cfg *config.Config, args []string, env commandEnv) (commandResult, error) {
 	fs := newFlagSet("move")
+	fs.SetOutput(env.out)
 	id := fs.String("id", "", "identifier of the menu item to move")
 	label := fs.String("label", "", "label of the menu item to move")
 	position := fs.Int("position", 0, "1-based position to move the item to")
+	output := outputFlag(fs)
 	if err := fs.Parse(args); err != nil {
-		return err
+		return commandResult{}, err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return commandResult{}, err
 	}
 
 	if *id == "" && *label == "" {
-		return errors.New("specify --id or --label for move")
+		return commandResult{}, errors.New("specify --id or --label for move")
 	}
 	if *position <= 0 {
-		return errors.New("--position must be greater than zero")
+		return commandResult{}, errors.New("--position must be greater than zero")
 	}
 
 	descriptor := ""
@@ -527,7 +835,7 @@ func handleMove(cfg *config.Config, args []string) error {
 		descriptor = fmt.Sprintf("label %q", *label)
 	}
 	if idx == -1 {
-		return fmt.Errorf("item with %s not found", descriptor)
+		return commandResult{}, fmt.Errorf("item with %s not found", descriptor)
 	}
 
 	item := cfg.Items[idx]
@@ -546,22 +854,41 @@ func handleMove(cfg *config.Config, args []string) error {
 	cfg.Items = menu.InsertItem(cfg.Items, target, item)
 	menu.EnsureSequentialOrder(&cfg.Items)
 
-	if err := config.Save(cfg); err != nil {
-		return err
+	if err := env.save(cfg, format); err != nil {
+		return commandResult{}, err
 	}
 
-	fmt.Printf("Moved menu item %s to position %d\n", item.ID, target+1)
-	return nil
+	message := fmt.Sprintf("Moved menu item %s to position %d", item.ID, target+1)
+	result := itemResult("moved", item, target+1)
+	return result, writeResult(env.out, format, result, message)
 }
 
-func handleList(cfg *config.Config) error {
+func handleList(cfg *config.Config, args []string) error {
+	fs := newFlagSet("list")
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	menu.EnsureSequentialOrder(&cfg.Items)
+
+	if format != outputTable {
+		records := make([]itemRecord, 0, len(cfg.Items))
+		for idx, item := range cfg.Items {
+			records = append(records, newItemRecord(idx+1, item))
+		}
+		return writeRecords(os.Stdout, format, records)
+	}
+
 	if len(cfg.Items) == 0 {
 		fmt.Println("No menu items configured")
 		return nil
 	}
 
-	menu.EnsureSequentialOrder(&cfg.Items)
-
 	fmt.Printf("%-5s %-38s %-8s %-12s %-20s %-20s\n", "Pos", "ID", "Type", "Parent", "Label", "Updated (UTC)")
 	for idx, item := range cfg.Items {
 		fmt.Printf("%-5d %-38s %-8s %-12s %-20s %-20s\n", idx+1, item.ID, item.Type, truncate(item.ParentID, 12), truncate(item.Label, 20), item.UpdatedUTC)
@@ -569,10 +896,272 @@ func handleList(cfg *config.Config) error {
 	return nil
 }
 
-func handleExport(cfg *config.Config) error {
+// handleTree prints the menu as the tray shows it. Like the tray it uses the
+// Tactical RMM menu when one is provided, unless --offline is set.
+func handleTree(cfg *config.Config, args []string, offline bool) error {
+	fs := newFlagSet("tree")
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	items, source := cfg.Items, sourceLocal
+	if !offline {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		trayData, err := trmm.FetchTrayData(ctx, nil, trmm.DetectOptions())
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: Tactical RMM: %v\n", err)
+		}
+		if trayData != nil && len(trayData.MenuItems) > 0 {
+			items, source = trayData.MenuItems, sourceTRMM
+		}
+	}
+	items = append([]config.MenuItem(nil), items...)
+	menu.EnsureSequentialOrder(&items)
+
+	nodes, orphans := menu.BuildTree(items, locale.Detect(cfg.Settings.Locale))
+	records := newTreeRecords(nodes, source)
+	if format != outputTable {
+		return writeTree(os.Stdout, format, records)
+	}
+
+	if len(items) == 0 {
+		fmt.Println("No menu items configured")
+		return nil
+	}
+	fmt.Printf("Menu from %s\n", source.description())
+	printTree(os.Stdout, records, "")
+	if len(orphans) > 0 {
+		fmt.Println()
+		fmt.Println("Not shown (parent missing or not a menu):")
+		for _, item := range orphans {
+			fmt.Printf("  %s (%s, id %s, parent %s)\n", displayLabel(item), item.Type, item.ID, item.ParentID)
+		}
+	}
+	return nil
+}
+
+func handleShow(cfg *config.Config, args []string) error {
+	fs := newFlagSet("show")
+	id := fs.String("id", "", "identifier of the menu item to show")
+	label := fs.String("label", "", "label of the menu item to show")
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+	if format == outputCSV {
+		return errors.New("show supports table, json, or yaml output")
+	}
+
+	if *id == "" && *label == "" {
+		return errors.New("specify --id or --label for show")
+	}
+
 	menu.EnsureSequentialOrder(&cfg.Items)
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	descriptor := ""
+	idx := -1
+	if *id != "" {
+		idx = findItemIndexByID(cfg.Items, *id)
+		descriptor = fmt.Sprintf("id %s", *id)
+	}
+	if idx == -1 && *label != "" {
+		idx = findItemIndexByLabel(cfg.Items, *label)
+		descriptor = fmt.Sprintf("label %q", *label)
+	}
+	if idx == -1 {
+		return fmt.Errorf("item with %s not found", descriptor)
+	}
+
+	detail := newItemDetail(cfg.Items, idx)
+	switch format {
+	case outputJSON:
+		return writeJSON(os.Stdout, detail)
+	case outputYAML:
+		return writeYAML(os.Stdout, detail)
+	}
+
+	fmt.Printf("%-16s %d\n", "position", detail.Position)
+	for _, field := range itemFields(detail.Item) {
+		fmt.Printf("%-16s %s\n", field[0], field[1])
+	}
+	if len(detail.Parents) > 0 || detail.MissingParent != "" {
+		chain := make([]string, 0, len(detail.Parents)+1)
+		if detail.MissingParent != "" {
+			chain = append(chain, fmt.Sprintf("missing id %s", detail.MissingParent))
+		}
+		for _, parent := range detail.Parents {
+			chain = append(chain, parent.String())
+		}
+		fmt.Printf("%-16s %s\n", "parent chain", strings.Join(chain, " > "))
+	}
+	for idx, child := range detail.Children {
+		name := ""
+		if idx == 0 {
+			name = "children"
+		}
+		fmt.Printf("%-16s %s\n", name, child.String())
+	}
+	return nil
+}
+
+func printTree(w io.Writer, records []treeRecord, indent string) {
+	for idx, record := range records {
+		branch, next := "├── ", "│   "
+		if idx == len(records)-1 {
+			branch, next = "└── ", "    "
+		}
+		details := []string{record.Type, "id " + record.ID}
+		if record.Disabled {
+			details = append(details, "disabled")
+		}
+		if record.Runtime {
+			details = append(details, "filled at runtime")
+		}
+		fmt.Fprintf(w, "%s%s%s (%s)\n", indent, branch, record.Label, strings.Join(details, ", "))
+		printTree(w, record.Children, indent+next)
+	}
+}
+
+// exitError ends the process with code without printing a message, for
+// commands whose exit status carries a result.
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// diffExitCode is returned by diff when the configurations differ; errors
+// exit with 1.
+const diffExitCode = 2
+
+func handleDiff(cfg *config.Config, args []string) error {
+	fs := newFlagSet("diff")
+	dataFlag := fs.String("data", "", "export payload to compare against")
+	fileFlag := fs.String("file", "", "file with an export payload, configuration JSON, or a config.b64 file")
+	userFlag := fs.String("user", "", "compare against the configuration of another local user")
+	trmmFlag := fs.Bool("trmm", false, "compare against the live Tactical RMM TrayMenu")
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	provided := providedFlags(fs)
+	sources := 0
+	for _, name := range []string{"data", "file", "user", "trmm"} {
+		if provided[name] {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return errors.New("specify exactly one of --data, --file, --user, or --trmm")
+	}
+
+	var other []config.MenuItem
+	var source string
+	switch {
+	case provided["data"]:
+		imported, err := config.Decode(*dataFlag)
+		if err != nil {
+			return err
+		}
+		other, source = imported.Items, "payload"
+	case provided["file"]:
+		content, err := os.ReadFile(*fileFlag)
+		if err != nil {
+			return fmt.Errorf("read payload file: %w", err)
+		}
+		imported, err := config.Decode(string(content))
+		if err != nil {
+			return err
+		}
+		other, source = imported.Items, *fileFlag
+	case provided["user"]:
+		account, err := user.Lookup(strings.TrimSpace(*userFlag))
+		if err != nil {
+			return err
+		}
+		path := config.UserPath(account.HomeDir)
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("configuration of user %s: %w", account.Username, err)
+		}
+		loaded, err := config.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		other, source = loaded.Items, "user "+account.Username
+	default:
+		if !*trmmFlag {
+			return errors.New("specify exactly one of --data, --file, --user, or --trmm")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		trayData, err := trmm.FetchTrayData(ctx, nil, trmm.DetectOptions())
+		if err != nil {
+			return fmt.Errorf("fetch Tactical RMM tray data: %w", err)
+		}
+		if trayData == nil {
+			return errors.New("Tactical RMM integration is not configured")
+		}
+		other, source = trayData.MenuItems, "Tactical RMM"
+	}
+
+	local := append([]config.MenuItem(nil), cfg.Items...)
+	other = append([]config.MenuItem(nil), other...)
+	menu.EnsureSequentialOrder(&local)
+	menu.EnsureSequentialOrder(&other)
+
+	changes := diff.Items(local, other)
+	records := make([]changeRecord, 0, len(changes))
+	for _, change := range changes {
+		records = append(records, newChangeRecord(change))
+	}
+	if err := writeChanges(os.Stdout, format, records, source); err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		return exitError{code: diffExitCode}
+	}
+	return nil
+}
+
+func handleExport(cfg *config.Config, args []string) error {
+	fs := newFlagSet("export")
+	id := fs.String("id", "", "export only this item and its descendants")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	menu.EnsureSequentialOrder(&cfg.Items)
+
+	exported := cfg
+	if *id != "" {
+		items := menu.Subtree(cfg.Items, *id)
+		if items == nil {
+			return fmt.Errorf("item with id %s not found", *id)
+		}
+		// The subtree becomes a top-level menu of its own; settings are
+		// not shared.
+		items[0].ParentID = ""
+		exported = &config.Config{Items: items}
+	}
+
+	data, err := json.MarshalIndent(exported, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal configuration: %w", err)
 	}
@@ -584,12 +1173,21 @@ func handleExport(cfg *config.Config) error {
 
 func handleImport(cfg *config.Config, args []string) error {
 	fs := newFlagSet("import")
-	dataFlag := fs.String("data", "", "base64-encoded configuration payload")
-	fileFlag := fs.String("file", "", "path to a file containing the base64 payload")
+	dataFlag := fs.String("data", "", "export payload or configuration JSON")
+	fileFlag := fs.String("file", "", "file with an export payload, configuration JSON, or a config.b64 file")
+	modeFlag := fs.String("mode", string(menu.ImportReplace), "how to combine the imported items with the menu: replace, merge, or append")
+	conflictFlag := fs.String("conflict", "", "resolution for duplicate IDs: keep, take, or renumber (default take for merge, renumber otherwise)")
+	parentFlag := fs.String("parent", "", "graft the top-level imported items below this menu item; with replace, they replace its entries")
+	freshIDs := fs.Bool("fresh-ids", false, "generate new IDs for all imported items")
+	output := outputFlag(fs)
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
 
 	if *dataFlag == "" && *fileFlag == "" {
 		return errors.New("provide --data or --file with the configuration payload")
@@ -598,33 +1196,74 @@ func handleImport(cfg *config.Config, args []string) error {
 		return errors.New("--data cannot be combined with --file")
 	}
 
-	payload := strings.TrimSpace(*dataFlag)
+	mode := menu.ImportMode(strings.ToLower(strings.TrimSpace(*modeFlag)))
+	switch mode {
+	case menu.ImportReplace, menu.ImportMerge, menu.ImportAppend:
+	default:
+		return fmt.Errorf("unknown import mode %q (use replace, merge, or append)", *modeFlag)
+	}
+	conflict := menu.ConflictStrategy(strings.ToLower(strings.TrimSpace(*conflictFlag)))
+	switch conflict {
+	case "", menu.ConflictKeep, menu.ConflictTake, menu.ConflictRenumber:
+	default:
+		return fmt.Errorf("unknown conflict strategy %q (use keep, take, or renumber)", *conflictFlag)
+	}
+	parentID := strings.TrimSpace(*parentFlag)
+	if mode == menu.ImportReplace && conflict != "" && parentID == "" {
+		return errors.New("--conflict requires --parent or --mode merge or append")
+	}
+
+	payload := *dataFlag
 	if *fileFlag != "" {
 		content, err := os.ReadFile(*fileFlag)
 		if err != nil {
 			return fmt.Errorf("read payload file: %w", err)
 		}
-		payload = strings.TrimSpace(string(content))
+		payload = string(content)
+	}
+	imported, err := config.Decode(payload)
+	if err != nil {
+		return err
 	}
 
-	if payload == "" {
-		return errors.New("configuration payload is empty")
+	if err := prepareImportedItems(imported.Items, parentID); err != nil {
+		return err
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(payload)
+	merged, err := menu.Import(cfg.Items, imported.Items, menu.ImportOptions{
+		Mode:     mode,
+		Conflict: conflict,
+		Parent:   parentID,
+		FreshIDs: *freshIDs,
+	})
 	if err != nil {
-		return fmt.Errorf("decode payload: %w", err)
+		return err
 	}
 
-	var imported config.Config
-	if err := json.Unmarshal(decoded, &imported); err != nil {
-		return fmt.Errorf("parse configuration: %w", err)
+	if err := validateParents(merged.Items); err != nil {
+		return err
 	}
 
+	before := append([]config.MenuItem(nil), cfg.Items...)
+	menu.EnsureSequentialOrder(&before)
+	changes := diff.Items(before, merged.Items)
+	cfg.Items = merged.Items
+	if err := saveConfig(cfg, format); err != nil {
+		return err
+	}
+
+	result := newImportResult(mode, len(imported.Items), changes, merged)
+	return writeImportResult(os.Stdout, format, result)
+}
+
+// prepareImportedItems checks items read from a payload: every item needs a
+// unique ID and must pass menu.ValidateItem, with top-level items validated
+// below parentID when they are grafted. Missing timestamps are filled in.
+func prepareImportedItems(items []config.MenuItem, parentID string) error {
 	now := time.Now().UTC().Format(time.RFC3339)
 	seen := make(map[string]struct{})
-	for idx := range imported.Items {
-		item := imported.Items[idx]
+	for idx := range items {
+		item := items[idx]
 		if item.ID == "" {
 			return fmt.Errorf("imported item at position %d is missing an id", idx+1)
 		}
@@ -640,74 +1279,516 @@ func handleImport(cfg *config.Config, args []string) error {
 			item.UpdatedUTC = item.CreatedUTC
 		}
 
-		if err := validateItem(item); err != nil {
+		// Validate top-level items where --parent places them, so an
+		// exported submenu can be grafted.
+		placed := item
+		if placed.ParentID == "" {
+			placed.ParentID = parentID
+		}
+		if err := menu.ValidateItem(placed); err != nil {
 			return fmt.Errorf("item %s invalid: %w", item.ID, err)
 		}
 
-		imported.Items[idx] = item
+		items[idx] = item
 	}
+	return nil
+}
 
-	for _, item := range imported.Items {
-		if err := menu.ValidateParent(imported.Items, item); err != nil {
+// validateParents checks that every parent reference in items resolves.
+func validateParents(items []config.MenuItem) error {
+	for _, item := range items {
+		if err := menu.ValidateParent(items, item); err != nil {
 			return fmt.Errorf("item %s invalid parent: %w", item.ID, err)
 		}
 	}
+	return nil
+}
 
-	menu.EnsureSequentialOrder(&imported.Items)
-	cfg.Items = imported.Items
-	if err := config.Save(cfg); err != nil {
+func handleSettings(cfg *config.Config, args []string) error {
+	fs := newFlagSet("settings")
+	terminal := fs.String("terminal", "", "terminal command template, e.g. \"alacritty -e {command}\" (empty to auto-detect)")
+	localeTag := fs.String("locale", "", "locale used for translated labels, e.g. \"de\" (empty to detect from LANG)")
+	tooltip := fs.String("tooltip", "", "tray tooltip template, e.g. \"GoTray – synced {{.LastSync}}\" (empty for the default)")
+	title := fs.String("title", "", "tray title template shown next to the icon where supported (empty for none)")
+	icon := fs.String("icon", "", "tray icon file used when Tactical RMM provides none (empty for the built-in icon)")
+	iconDark := fs.String("icon-dark", "", "tray icon file used on dark desktops (empty to always use --icon)")
+	themeSetting := fs.String("theme", "", "desktop colour scheme used to choose the icon: auto, light, or dark")
+	stateIcons := stateIconFlag{}
+	fs.Var(stateIcons, "state-icon", "icon file for a sync state as state=path, e.g. error=/opt/icons/error.png (repeatable; empty path restores the generated icon)")
+	badgeCommand := fs.String("badge-command", "", "command whose output sets the tray icon badge (empty to remove the badge)")
+	badgeArgs := fs.String("badge-args", "", "comma-separated arguments for --badge-command")
+	badgeURL := fs.String("badge-url", "", "URL polled with GET for the tray icon badge (empty to remove the badge)")
+	badgeHeaders := headerFlag{}
+	fs.Var(badgeHeaders, "badge-header", "HTTP header sent to --badge-url as \"Name: value\" (repeatable)")
+	badgeColor := fs.String("badge-color", "", "badge colour as #rrggbb or a name such as red or blue (defaults to red)")
+	badgeIntervalSeconds := fs.Int("badge-interval", 0, "seconds between badge updates (defaults to 60)")
+	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	provided := providedFlags(fs)
+
+	if len(provided) == 0 {
+		fmt.Printf("%-12s %s\n", "terminal", displaySetting(cfg.Settings.Terminal, "auto-detect"))
+		detected := "none detected"
+		if tag := locale.Detect(""); tag != "" {
+			detected = "detected: " + tag
+		}
+		fmt.Printf("%-12s %s\n", "locale", displaySetting(cfg.Settings.Locale, detected))
+		fmt.Printf("%-12s %s\n", "tooltip", displaySetting(cfg.Settings.Tooltip, "GoTray"))
+		fmt.Printf("%-12s %s\n", "title", displaySetting(cfg.Settings.Title, "none"))
+		fmt.Printf("%-12s %s\n", "icon", displaySetting(cfg.Settings.Icon, "built-in"))
+		fmt.Printf("%-12s %s\n", "icon-dark", displaySetting(cfg.Settings.IconDark, "same as icon"))
+		detectedScheme := "auto"
+		if scheme := theme.Detect(""); scheme != theme.Unknown {
+			detectedScheme = "auto, detected: " + string(scheme)
+		}
+		fmt.Printf("%-12s %s\n", "theme", displaySetting(cfg.Settings.Theme, detectedScheme))
+		for _, state := range menu.CustomizableIconStates {
+			fmt.Printf("%-12s %s\n", "icon."+string(state), displaySetting(cfg.Settings.StateIcons[string(state)], "generated"))
+		}
+		fmt.Printf("%-12s %s\n", "badge", displaySetting(describeBadge(cfg.Settings.Badge), "none"))
+		return nil
+	}
+
+	if provided["terminal"] {
+		cfg.Settings.Terminal = *terminal
+	}
+	if provided["locale"] {
+		cfg.Settings.Locale = *localeTag
+	}
+	if provided["tooltip"] {
+		cfg.Settings.Tooltip = *tooltip
+	}
+	if provided["title"] {
+		cfg.Settings.Title = *title
+	}
+	if provided["icon"] {
+		path, err := absolutePath(*icon)
+		if err != nil {
+			return err
+		}
+		cfg.Settings.Icon = path
+	}
+	if provided["icon-dark"] {
+		path, err := absolutePath(*iconDark)
+		if err != nil {
+			return err
+		}
+		cfg.Settings.IconDark = path
+	}
+	if provided["theme"] {
+		cfg.Settings.Theme = *themeSetting
+	}
+	if provided["state-icon"] {
+		if cfg.Settings.StateIcons == nil {
+			cfg.Settings.StateIcons = make(map[string]string)
+		}
+		for state, path := range stateIcons {
+			if path == "" {
+				delete(cfg.Settings.StateIcons, state)
+				continue
+			}
+			cfg.Settings.StateIcons[state] = path
+		}
+	}
+	if provided["badge-command"] || provided["badge-args"] || provided["badge-url"] || provided["badge-header"] || provided["badge-color"] || provided["badge-interval"] {
+		badge := config.BadgeSettings{}
+		if cfg.Settings.Badge != nil {
+			badge = *cfg.Settings.Badge
+		}
+		if provided["badge-command"] {
+			badge.Command = strings.TrimSpace(*badgeCommand)
+			badge.URL = ""
+			badge.Headers = nil
+		}
+		if provided["badge-args"] {
+			badge.Arguments = parseList(*badgeArgs)
+		}
+		if provided["badge-url"] {
+			badge.URL = strings.TrimSpace(*badgeURL)
+			badge.Command = ""
+			badge.Arguments = nil
+		}
+		if provided["badge-header"] {
+			badge.Headers = badgeHeaders.value()
+		}
+		if provided["badge-color"] {
+			badge.Color = strings.TrimSpace(*badgeColor)
+		}
+		if provided["badge-interval"] {
+			badge.IntervalSeconds = *badgeIntervalSeconds
+		}
+		if provided["badge-command"] && provided["badge-url"] && strings.TrimSpace(*badgeCommand) != "" {
+			badge.Command = strings.TrimSpace(*badgeCommand)
+		}
+		if badge.Command == "" && badge.URL == "" && (provided["badge-command"] || provided["badge-url"]) {
+			cfg.Settings.Badge = nil
+		} else {
+			cfg.Settings.Badge = &badge
+		}
+	}
+	if err := normalizeSettings(&cfg.Settings); err != nil {
+		return err
+	}
+	if err := saveConfig(cfg, outputTable); err != nil {
+		return err
+	}
+
+	fmt.Println("Updated tray settings")
+	return nil
+}
+
+// normalizeSettings validates the tray settings and rewrites them in the form
+// they are saved in, so settings and edit store the same values.
+func normalizeSettings(settings *config.Settings) error {
+	settings.Terminal = strings.TrimSpace(settings.Terminal)
+
+	settings.Locale = strings.TrimSpace(settings.Locale)
+	if settings.Locale != "" {
+		if err := locale.Validate(settings.Locale); err != nil {
+			return err
+		}
+		settings.Locale = locale.Normalize(settings.Locale)
+	}
+
+	if err := menu.ValidateStatusTemplate(settings.Tooltip); err != nil {
+		return fmt.Errorf("invalid tooltip template: %w", err)
+	}
+	settings.Tooltip = strings.TrimSpace(settings.Tooltip)
+	if err := menu.ValidateStatusTemplate(settings.Title); err != nil {
+		return fmt.Errorf("invalid title template: %w", err)
+	}
+	settings.Title = strings.TrimSpace(settings.Title)
+
+	settings.Icon = strings.TrimSpace(settings.Icon)
+	if settings.Icon != "" && !filepath.IsAbs(settings.Icon) {
+		return errors.New("icon must be an absolute path")
+	}
+	settings.IconDark = strings.TrimSpace(settings.IconDark)
+	if settings.IconDark != "" && !filepath.IsAbs(settings.IconDark) {
+		return errors.New("iconDark must be an absolute path")
+	}
+
+	if err := theme.Validate(settings.Theme); err != nil {
+		return err
+	}
+	settings.Theme = strings.ToLower(strings.TrimSpace(settings.Theme))
+	if settings.Theme == theme.Auto {
+		settings.Theme = ""
+	}
+
+	for state, path := range settings.StateIcons {
+		if _, err := menu.ParseIconState(state); err != nil {
+			return err
+		}
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("state icon %s must be an absolute path", state)
+		}
+	}
+	if len(settings.StateIcons) == 0 {
+		settings.StateIcons = nil
+	}
 
-	fmt.Printf("Imported %d menu items\n", len(cfg.Items))
+	if settings.Badge != nil {
+		if err := menu.ValidateBadge(*settings.Badge); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func validateItem(item config.MenuItem) error {
-	switch item.Type {
-	case config.MenuItemText:
-		if item.Label == "" {
-			return errors.New("text items require --label")
+func describeBadge(badge *config.BadgeSettings) string {
+	if badge == nil {
+		return ""
+	}
+	source := strings.TrimSpace(strings.Join(append([]string{badge.Command}, badge.Arguments...), " "))
+	if badge.URL != "" {
+		source = badge.URL
+	}
+	if source == "" {
+		return ""
+	}
+	interval := badge.IntervalSeconds
+	if interval <= 0 {
+		interval = 60
+	}
+	color := badge.Color
+	if color == "" {
+		color = "red"
+	}
+	return fmt.Sprintf("%s (every %ds, %s)", source, interval, color)
+}
+
+func displaySetting(value, fallback string) string {
+	if value == "" {
+		return "(" + fallback + ")"
+	}
+	return value
+}
+
+func handleRuns(args []string) error {
+	fs := newFlagSet("runs")
+	runID := fs.String("id", "", "run identifier whose captured output should be printed")
+	itemID := fs.String("item", "", "only list runs for this menu item id")
+	limit := fs.Int("limit", 20, "maximum number of runs to list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *runID != "" {
+		rec, err := runlog.Find(*runID)
+		if err != nil {
+			return fmt.Errorf("run %s: %w", *runID, err)
+		}
+		output, err := runlog.ReadOutput(*rec)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Run:       %s\n", rec.ID)
+		fmt.Printf("Item:      %s (%s)\n", rec.ItemID, rec.Label)
+		if rec.Command != "" {
+			fmt.Printf("Command:   %s\n", strings.TrimSpace(rec.Command+" "+strings.Join(rec.Arguments, " ")))
 		}
-	case config.MenuItemMenu:
-		if item.Label == "" {
-			return errors.New("menu items require --label")
+		if rec.WorkingDir != "" {
+			fmt.Printf("Directory: %s\n", rec.WorkingDir)
 		}
-		if item.ParentID == "" {
-			return errors.New("menu items require --parent")
+		fmt.Printf("Started:   %s\n", rec.StartedUTC)
+		if rec.Finished() {
+			fmt.Printf("Duration:  %s\n", rec.Duration())
+			fmt.Printf("Exit code: %d\n", rec.ExitCode)
+		} else {
+			fmt.Println("Result:    none yet; still running or interrupted when GoTray quit")
 		}
-	case config.MenuItemCommand:
-		if item.Label == "" {
-			return errors.New("command items require --label")
+		if rec.Error != "" {
+			fmt.Printf("Error:     %s\n", rec.Error)
 		}
-		if item.Command == "" {
-			return errors.New("command items require --command")
+		for idx, step := range rec.Steps {
+			status := fmt.Sprintf("exit %d in %s", step.ExitCode, (time.Duration(step.DurationMS) * time.Millisecond).Round(time.Millisecond))
+			switch {
+			case step.Skipped:
+				status = "skipped"
+			case step.Error != "":
+				status = "failed: " + step.Error
+			}
+			fmt.Printf("Step %d:    %s (%s)\n", idx+1, strings.TrimSpace(step.Command+" "+strings.Join(step.Arguments, " ")), status)
+		}
+		if rec.Truncated {
+			fmt.Printf("Output truncated to the last %d bytes\n", runlog.MaxOutputBytes)
+		}
+		fmt.Println()
+		os.Stdout.Write(output)
+		return nil
+	}
+
+	records, err := runlog.List(strings.TrimSpace(*itemID), *limit)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Println("No command runs recorded")
+		return nil
+	}
+
+	fmt.Printf("%-30s %-12s %-5s %-10s %-20s %-20s\n", "Run", "Item", "Exit", "Duration", "Started (UTC)", "Label")
+	for _, rec := range records {
+		exit, duration := strconv.Itoa(rec.ExitCode), rec.Duration().Round(time.Millisecond).String()
+		if !rec.Finished() {
+			exit, duration = "-", "-"
+		}
+		fmt.Printf("%-30s %-12s %-5s %-10s %-20s %-20s\n", rec.ID, truncate(rec.ItemID, 12), exit, duration, rec.StartedUTC, truncate(rec.Label, 20))
+	}
+	return nil
+}
+
+// extractLocalizedFlags removes --label.<locale> and --description.<locale>
+// switches from args, since the flag package only accepts predeclared names.
+// Values are returned keyed by normalised locale; an empty value marks a
+// translation for removal.
+func extractLocalizedFlags(args []string) ([]string, map[string]string, map[string]string, error) {
+	rest := make([]string, 0, len(args))
+	labels := make(map[string]string)
+	descriptions := make(map[string]string)
+
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		if arg == "--" {
+			rest = append(rest, args[idx:]...)
+			break
 		}
-	case config.MenuItemURL:
-		if item.Label == "" {
-			return errors.New("URL items require --label")
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			rest = append(rest, arg)
+			continue
 		}
-		if item.URL == "" {
-			return errors.New("URL items require --url")
+		name, value, hasValue := strings.Cut(name, "=")
+		field, tag, ok := strings.Cut(name, ".")
+		if !ok || (field != "label" && field != "description") {
+			rest = append(rest, arg)
+			continue
 		}
-	case config.MenuItemDivider:
-		if item.Label == "" {
-			return errors.New("divider items require --label")
+		if err := locale.Validate(tag); err != nil {
+			return nil, nil, nil, fmt.Errorf("--%s: %w", name, err)
 		}
-	case config.MenuItemRefresh:
-		if item.Label == "" {
-			return errors.New("refresh items require --label")
+		if !hasValue {
+			if idx+1 >= len(args) {
+				return nil, nil, nil, fmt.Errorf("flag needs an argument: --%s", name)
+			}
+			idx++
+			value = args[idx]
 		}
-	case config.MenuItemQuit:
-		if item.Label == "" {
-			return errors.New("quit items require --label")
+		if field == "label" {
+			labels[locale.Normalize(tag)] = value
+		} else {
+			descriptions[locale.Normalize(tag)] = value
 		}
-	default:
-		return fmt.Errorf("unsupported menu type: %s", item.Type)
 	}
+	return rest, labels, descriptions, nil
+}
+
+// mergeTranslations applies updates to existing translations, deleting
+// locales whose new value is empty.
+func mergeTranslations(existing, updates map[string]string) map[string]string {
+	if len(updates) == 0 {
+		return existing
+	}
+	merged := make(map[string]string, len(existing)+len(updates))
+	for tag, value := range existing {
+		merged[tag] = value
+	}
+	for tag, value := range updates {
+		if value == "" {
+			delete(merged, tag)
+			continue
+		}
+		merged[tag] = value
+	}
+	return withoutEmpty(merged)
+}
+
+func withoutEmpty(values map[string]string) map[string]string {
+	out := make(map[string]string, len(values))
+	for key, value := range values {
+		if value != "" {
+			out[key] = value
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// runsCommand reports whether items of the given type use the --command,
+// --args, and --workdir fields.
+func runsCommand(itemType config.MenuItemType) bool {
+	return itemType == config.MenuItemCommand || itemType == config.MenuItemGenerator
+}
+
+// headerFlag collects repeated --header "Name: value" flags.
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+h[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (h headerFlag) Set(raw string) error {
+	name, value, ok := strings.Cut(raw, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("header must be formatted as \"Name: value\", got %q", raw)
+	}
+	h[http.CanonicalHeaderKey(name)] = strings.TrimSpace(value)
 	return nil
 }
 
+func (h headerFlag) value() map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	return map[string]string(h)
+}
+
+// stateIconFlag collects repeated --state-icon state=path values.
+type stateIconFlag map[string]string
+
+func (s stateIconFlag) String() string {
+	states := make([]string, 0, len(s))
+	for state := range s {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	parts := make([]string, 0, len(states))
+	for _, state := range states {
+		parts = append(parts, state+"="+s[state])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s stateIconFlag) Set(raw string) error {
+	name, path, ok := strings.Cut(raw, "=")
+	if !ok {
+		return fmt.Errorf("state icon must be formatted as state=path, got %q", raw)
+	}
+	state, err := menu.ParseIconState(name)
+	if err != nil {
+		return err
+	}
+	path, err = absolutePath(path)
+	if err != nil {
+		return err
+	}
+	s[string(state)] = path
+	return nil
+}
+
+// absolutePath trims path and makes it absolute so configured files do not
+// depend on the directory the tray is started from. Empty stays empty.
+func absolutePath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" || filepath.IsAbs(path) {
+		return path, nil
+	}
+	return filepath.Abs(path)
+}
+
+func parseSteps(raw string) ([]config.WorkflowStep, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return nil, nil
+	}
+	var steps []config.WorkflowStep
+	if err := json.Unmarshal([]byte(trimmed), &steps); err != nil {
+		return nil, fmt.Errorf("parse --steps: %w", err)
+	}
+	return steps, nil
+}
+
+// parseArguments reads --args as a JSON array when it starts with "[", so
+// arguments may contain commas, and as a comma-separated list otherwise.
+func parseArguments(raw string) ([]string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(raw), "[") {
+		return parseList(raw), nil
+	}
+	var args []string
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return nil, fmt.Errorf("parse --args: %w", err)
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+	return args, nil
+}
+
 func parseList(raw string) []string {
 	if raw == "" {
 		return nil
@@ -739,6 +1820,16 @@ func newFlagSet(name string) *flag.FlagSet {
 	return fs
 }
 
+// providedFlags reports which flags were explicitly set on the command line so
+// boolean and numeric switches can distinguish "unchanged" from a zero value.
+func providedFlags(fs *flag.FlagSet) map[string]bool {
+	provided := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		provided[f.Name] = true
+	})
+	return provided
+}
+
 func findItemIndexByID(items []config.MenuItem, id string) int {
 	for i := range items {
 		if items[i].ID == id {
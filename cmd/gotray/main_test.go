@@ -1,10 +1,21 @@
 package main
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+)
 
 func TestParseGlobalFlagsIgnoresBuildXWithSeparateValue(t *testing.T) {
 	args := []string{"add", "-X", "internal/trmm.embeddedAPIKey=value", "--debug"}
-	filtered, debug, offline, importTRMM, err := parseGlobalFlags(args)
+	filtered, debug, offline, importTRMM, dryRun, err := parseGlobalFlags(args)
 	if err != nil {
 		t.Fatalf("parseGlobalFlags returned error: %v", err)
 	}
@@ -17,6 +28,9 @@ func TestParseGlobalFlagsIgnoresBuildXWithSeparateValue(t *testing.T) {
 	if importTRMM {
 		t.Fatalf("importTRMM flag should not be set")
 	}
+	if dryRun {
+		t.Fatalf("dryRun flag should not be set")
+	}
 	if len(filtered) != 1 || filtered[0] != "add" {
 		t.Fatalf("unexpected filtered args: %#v", filtered)
 	}
@@ -24,7 +38,7 @@ func TestParseGlobalFlagsIgnoresBuildXWithSeparateValue(t *testing.T) {
 
 func TestParseGlobalFlagsIgnoresBuildXInline(t *testing.T) {
 	args := []string{"add", "-Xinternal/trmm.embeddedAPIKey=value", "-ImportTRMM=true"}
-	filtered, debug, offline, importTRMM, err := parseGlobalFlags(args)
+	filtered, debug, offline, importTRMM, dryRun, err := parseGlobalFlags(args)
 	if err != nil {
 		t.Fatalf("parseGlobalFlags returned error: %v", err)
 	}
@@ -37,6 +51,9 @@ func TestParseGlobalFlagsIgnoresBuildXInline(t *testing.T) {
 	if !importTRMM {
 		t.Fatalf("importTRMM flag should be set")
 	}
+	if dryRun {
+		t.Fatalf("dryRun flag should not be set")
+	}
 	if len(filtered) != 1 || filtered[0] != "add" {
 		t.Fatalf("unexpected filtered args: %#v", filtered)
 	}
@@ -44,12 +61,12 @@ func TestParseGlobalFlagsIgnoresBuildXInline(t *testing.T) {
 
 func TestParseGlobalFlagsIgnoresQuotedBuildX(t *testing.T) {
 	args := []string{"add", "-X\"internal/trmm.embeddedAPIKey=value\""}
-	filtered, debug, offline, importTRMM, err := parseGlobalFlags(args)
+	filtered, debug, offline, importTRMM, dryRun, err := parseGlobalFlags(args)
 	if err != nil {
 		t.Fatalf("parseGlobalFlags returned error: %v", err)
 	}
-	if debug || offline || importTRMM {
-		t.Fatalf("unexpected flag states: debug=%v offline=%v importTRMM=%v", debug, offline, importTRMM)
+	if debug || offline || importTRMM || dryRun {
+		t.Fatalf("unexpected flag states: debug=%v offline=%v importTRMM=%v dryRun=%v", debug, offline, importTRMM, dryRun)
 	}
 	if len(filtered) != 1 || filtered[0] != "add" {
 		t.Fatalf("unexpected filtered args: %#v", filtered)
@@ -58,7 +75,7 @@ func TestParseGlobalFlagsIgnoresQuotedBuildX(t *testing.T) {
 
 func TestParseGlobalFlagsIgnoresWrappedBuildX(t *testing.T) {
 	args := []string{"add", "\"-Xinternal/trmm.embeddedAPIKey=value\"", "--offline"}
-	filtered, debug, offline, importTRMM, err := parseGlobalFlags(args)
+	filtered, debug, offline, importTRMM, dryRun, err := parseGlobalFlags(args)
 	if err != nil {
 		t.Fatalf("parseGlobalFlags returned error: %v", err)
 	}
@@ -71,7 +88,389 @@ func TestParseGlobalFlagsIgnoresWrappedBuildX(t *testing.T) {
 	if importTRMM {
 		t.Fatalf("importTRMM flag should not be set")
 	}
+	if dryRun {
+		t.Fatalf("dryRun flag should not be set")
+	}
 	if len(filtered) != 1 || filtered[0] != "add" {
 		t.Fatalf("unexpected filtered args: %#v", filtered)
 	}
 }
+
+func TestParseGlobalFlagsKeepsSubcommandValues(t *testing.T) {
+	args := []string{"settings", "--state-icon", "offline=/opt/icons/offline.png", "-debug=true"}
+	filtered, debug, offline, _, _, err := parseGlobalFlags(args)
+	if err != nil {
+		t.Fatalf("parseGlobalFlags returned error: %v", err)
+	}
+	if !debug || offline {
+		t.Fatalf("unexpected flag states: debug=%v offline=%v", debug, offline)
+	}
+	if len(filtered) != 3 || filtered[2] != "offline=/opt/icons/offline.png" {
+		t.Fatalf("unexpected filtered args: %#v", filtered)
+	}
+}
+
+func TestParseGlobalFlagsReadsUnprefixedGlobals(t *testing.T) {
+	args := []string{"debug=true", "console=true", "list", "offline=true"}
+	filtered, debug, offline, _, _, err := parseGlobalFlags(args)
+	if err != nil {
+		t.Fatalf("parseGlobalFlags returned error: %v", err)
+	}
+	if !debug || !offline {
+		t.Fatalf("unexpected flag states: debug=%v offline=%v", debug, offline)
+	}
+	if !reflect.DeepEqual(filtered, []string{"list"}) {
+		t.Fatalf("unexpected filtered args: %#v", filtered)
+	}
+}
+
+func TestParseGlobalFlagsDryRun(t *testing.T) {
+	args := []string{"--dry-run", "move", "--id", "10", "--position", "2"}
+	filtered, _, _, _, dryRun, err := parseGlobalFlags(args)
+	if err != nil {
+		t.Fatalf("parseGlobalFlags returned error: %v", err)
+	}
+	if !dryRun {
+		t.Fatalf("dryRun flag should be set")
+	}
+	if !reflect.DeepEqual(filtered, args[1:]) {
+		t.Fatalf("unexpected filtered args: %#v", filtered)
+	}
+	if _, _, _, _, _, err := parseGlobalFlags([]string{"--dry-run=maybe"}); err == nil {
+		t.Fatalf("expected an invalid --dry-run value to be rejected")
+	}
+}
+
+func TestExtractLocalizedFlags(t *testing.T) {
+	args := []string{"--type", "text", "--label", "Quit", "--label.de", "Beenden", "--description.pt_BR=Sair do app", "--", "--label.fr"}
+	rest, labels, descriptions, err := extractLocalizedFlags(args)
+	if err != nil {
+		t.Fatalf("extractLocalizedFlags returned error: %v", err)
+	}
+	if want := []string{"--type", "text", "--label", "Quit", "--", "--label.fr"}; !reflect.DeepEqual(rest, want) {
+		t.Fatalf("unexpected remaining args: %#v", rest)
+	}
+	if labels["de"] != "Beenden" || descriptions["pt-br"] != "Sair do app" {
+		t.Fatalf("unexpected translations: %#v %#v", labels, descriptions)
+	}
+
+	if _, _, _, err := extractLocalizedFlags([]string{"--label.de"}); err == nil {
+		t.Fatalf("expected an error for a missing value")
+	}
+}
+
+func TestMergeTranslations(t *testing.T) {
+	merged := mergeTranslations(map[string]string{"de": "Beenden", "fr": "Quitter"}, map[string]string{"de": "", "es": "Salir"})
+	if want := map[string]string{"fr": "Quitter", "es": "Salir"}; !reflect.DeepEqual(merged, want) {
+		t.Fatalf("unexpected merge result: %#v", merged)
+	}
+}
+
+func TestPreviewConfigLeavesConfigurationUntouched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	t.Setenv("GOTRAY_CONFIG_PATH", path)
+	saved := &config.Config{Items: []config.MenuItem{{ID: "10", Order: 10, Type: config.MenuItemText, Label: "Hello"}}}
+	if err := config.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := &config.Config{Items: []config.MenuItem{{ID: "10", Order: 10, Type: config.MenuItemText, Label: "Goodbye"}}}
+	if err := previewConfig(changed, outputTable); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatalf("expected the dry run to leave the configuration file unchanged")
+	}
+}
+
+func TestImportAcceptsConfigurationJSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GOTRAY_CONFIG_PATH", filepath.Join(dir, "config"))
+	path := filepath.Join(dir, "menu.json")
+	if err := os.WriteFile(path, []byte(`{"items": [{"id": "10", "order": 10, "type": "text", "label": "Hello"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	if err := handleImport(cfg, []string{"--file", path, "--output", "json"}); err != nil {
+		t.Fatalf("handleImport: %v", err)
+	}
+	if len(cfg.Items) != 1 || cfg.Items[0].Label != "Hello" {
+		t.Fatalf("unexpected items %+v", cfg.Items)
+	}
+}
+
+func TestExportedSubtreeImportsBelowParent(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GOTRAY_CONFIG_PATH", filepath.Join(dir, "config"))
+	items := func() []config.MenuItem {
+		return []config.MenuItem{
+			{ID: "10", Order: 10, Type: config.MenuItemGenerator, Label: "Hosts", Command: "hosts"},
+			{ID: "10.10", Order: 20, ParentID: "10", Type: config.MenuItemMenu, Label: "Dev tools"},
+			{ID: "10.10.1", Order: 10, ParentID: "10.10", Type: config.MenuItemCommand, Label: "Build", Command: "make"},
+			{ID: "10.20", Order: 10, ParentID: "10", Type: config.MenuItemText, Label: "Other"},
+		}
+	}
+
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(original *os.File) { os.Stdout = original }(os.Stdout)
+	os.Stdout = stdout
+	if err := handleExport(&config.Config{Items: items()}, []string{"--id", "10.10"}); err != nil {
+		t.Fatalf("handleExport: %v", err)
+	}
+	payload, err := os.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Items: items()}
+	cfg.Items[1].Label = "Changed"
+	if err := handleImport(cfg, []string{"--data", string(payload)}); err == nil {
+		t.Fatalf("expected a submenu to be rejected as the whole menu")
+	}
+	if err := handleImport(cfg, []string{"--data", string(payload), "--parent", "10", "--output", "json"}); err != nil {
+		t.Fatalf("handleImport: %v", err)
+	}
+	if len(cfg.Items) != 3 || cfg.Items[1].ID != "10.10" || cfg.Items[1].ParentID != "10" || cfg.Items[1].Label != "Dev tools" || cfg.Items[2].ParentID != "10.10" {
+		t.Fatalf("expected the exported submenu to replace the entries of 10, got %+v", cfg.Items)
+	}
+}
+
+func TestDryRunKeepsMachineReadableOutputParseable(t *testing.T) {
+	t.Setenv("GOTRAY_CONFIG_PATH", filepath.Join(t.TempDir(), "config"))
+	defer func(save saveFunc) { saveConfig = save }(saveConfig)
+	saveConfig = previewConfig
+
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(original *os.File) { os.Stdout = original }(os.Stdout)
+	os.Stdout = stdout
+
+	cfg := &config.Config{}
+	if err := handleCLI(cfg, []string{"add", "--type", "text", "--label", "Hi", "--output", "json"}, true); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	data, err := os.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result commandResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("expected only the JSON result on stdout, got %q: %v", data, err)
+	}
+	if result.Action != "added" || result.Label != "Hi" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestApplyOperationsResolvesRefs(t *testing.T) {
+	ops, err := parseOperations([]byte(`[
+		{"op": "add", "ref": "hosts", "type": "generator", "label": "Hosts", "command": "hosts"},
+		{"op": "add", "type": "command", "label": "Ping", "command": "ping", "args": ["-c", 1], "parent": "$hosts"},
+		{"op": "update", "id": "$hosts", "label": "Servers"}
+	]`))
+	if err != nil {
+		t.Fatalf("parseOperations: %v", err)
+	}
+
+	cfg := &config.Config{}
+	results, err := applyOperations(cfg, ops)
+	if err != nil {
+		t.Fatalf("applyOperations: %v", err)
+	}
+	if len(results) != 3 || results[1].ID != "10.1" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if len(cfg.Items) != 2 || cfg.Items[0].Label != "Servers" || cfg.Items[1].ParentID != "10" {
+		t.Fatalf("unexpected items %+v", cfg.Items)
+	}
+	if !reflect.DeepEqual(cfg.Items[1].Arguments, []string{"-c", "1"}) {
+		t.Fatalf("unexpected arguments %v", cfg.Items[1].Arguments)
+	}
+}
+
+func TestApplyOperationsPassesStructuredValues(t *testing.T) {
+	ops, err := parseOperations([]byte(`[
+		{"op": "add", "type": "command", "label": "Grep", "command": "grep", "args": ["a,b", "-e", 2]},
+		{"op": "add", "type": "http", "label": "Hook", "url": "https://example.com/hook", "header": {"authorization": "Token a,b", "X-Count": 3}}
+	]`))
+	if err != nil {
+		t.Fatalf("parseOperations: %v", err)
+	}
+
+	cfg := &config.Config{}
+	if _, err := applyOperations(cfg, ops); err != nil {
+		t.Fatalf("applyOperations: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Items[0].Arguments, []string{"a,b", "-e", "2"}) {
+		t.Fatalf("expected arguments with commas to stay intact, got %q", cfg.Items[0].Arguments)
+	}
+	want := map[string]string{"Authorization": "Token a,b", "X-Count": "3"}
+	if !reflect.DeepEqual(cfg.Items[1].Headers, want) {
+		t.Fatalf("expected headers %v, got %v", want, cfg.Items[1].Headers)
+	}
+
+	if args, err := parseArguments(`["x, y"]`); err != nil || !reflect.DeepEqual(args, []string{"x, y"}) {
+		t.Fatalf("expected --args to accept a JSON array, got %q, %v", args, err)
+	}
+	if _, err := parseArguments(`["x"`); err == nil {
+		t.Fatalf("expected a malformed JSON array to be rejected")
+	}
+}
+
+func TestApplyOperationsStopsAtFirstError(t *testing.T) {
+	ops, err := parseOperations([]byte(`[
+		{"op": "add", "type": "text", "label": "Hello"},
+		{"op": "delete", "id": "99"}
+	]`))
+	if err != nil {
+		t.Fatalf("parseOperations: %v", err)
+	}
+
+	saved := false
+	defer func(save saveFunc) { saveConfig = save }(saveConfig)
+	saveConfig = func(*config.Config, outputFormat) error {
+		saved = true
+		return nil
+	}
+
+	_, err = applyOperations(&config.Config{}, ops)
+	var opErr *operationError
+	if !errors.As(err, &opErr) || opErr.index != 1 || opErr.op != "delete" {
+		t.Fatalf("expected the missing item to fail the second operation, got %v", err)
+	}
+	if err.Error() != "operation 2 (delete): "+opErr.err.Error() {
+		t.Fatalf("unexpected message %q", err)
+	}
+	if saved {
+		t.Fatalf("expected the batch not to save the configuration")
+	}
+	if _, err := parseOperations([]byte(`[{"op": "move", "ref": "x"}]`)); err == nil {
+		t.Fatalf("expected ref to be rejected outside add")
+	}
+}
+
+func TestDeleteRemovesNestedItemsOnlyWhenRecursive(t *testing.T) {
+	items := func() []config.MenuItem {
+		return []config.MenuItem{
+			{ID: "10", Order: 20, Type: config.MenuItemGenerator, Label: "Tools", Command: "tools"},
+			{ID: "10.1", Order: 10, ParentID: "10", Type: config.MenuItemMenu, Label: "Nested"},
+			{ID: "10.1.1", Order: 10, ParentID: "10.1", Type: config.MenuItemText, Label: "Deep"},
+			{ID: "20", Order: 10, Type: config.MenuItemText, Label: "Bye"},
+		}
+	}
+	var out bytes.Buffer
+	env := commandEnv{out: &out, save: func(*config.Config, outputFormat) error { return nil }}
+
+	cfg := &config.Config{Items: items()}
+	result, err := handleDelete(cfg, []string{"--label", "Tools"}, env)
+	if err != nil {
+		t.Fatalf("handleDelete: %v", err)
+	}
+	if len(cfg.Items) != 3 || result.Count != 1 {
+		t.Fatalf("expected only the item itself to be deleted, got %+v", cfg.Items)
+	}
+
+	out.Reset()
+	cfg = &config.Config{Items: items()}
+	result, err = handleDelete(cfg, []string{"--label", "Tools", "--recursive"}, env)
+	if err != nil {
+		t.Fatalf("handleDelete: %v", err)
+	}
+	if len(cfg.Items) != 1 || cfg.Items[0].ID != "20" {
+		t.Fatalf("expected the item and its contents to be deleted, got %+v", cfg.Items)
+	}
+	if result.ID != "10" || result.Count != 3 || !strings.Contains(out.String(), "and 2 nested items") {
+		t.Fatalf("unexpected result %+v with output %q", result, out.String())
+	}
+}
+
+func TestDecodeEditedStripsHeaderAndRejectsUnknownFields(t *testing.T) {
+	body := "items:\n  - id: \"10\"\n    type: text\n    label: Hello\n    body: |\n      # kept\n"
+	text := editHeader(outputYAML, errors.New("previous failure")) + body
+	if got := stripEditHeader(text, outputYAML); got != body {
+		t.Fatalf("expected only the header to be removed, got %q", got)
+	}
+
+	cfg, err := decodeEdited(body, outputYAML)
+	if err != nil {
+		t.Fatalf("decodeEdited: %v", err)
+	}
+	if len(cfg.Items) != 1 || cfg.Items[0].Body != "# kept\n" {
+		t.Fatalf("unexpected items %+v", cfg.Items)
+	}
+	if err := validateEdited(cfg); err != nil {
+		t.Fatalf("validateEdited: %v", err)
+	}
+
+	if _, err := decodeEdited(`{"items": [{"id": "10", "colour": "red"}]}`, outputJSON); err == nil {
+		t.Fatalf("expected unknown fields to be rejected")
+	}
+	if err := validateEdited(&config.Config{Items: []config.MenuItem{{ID: "10", Type: config.MenuItemText, Label: "A"}, {ID: "10", Type: config.MenuItemText, Label: "B"}}}); err == nil {
+		t.Fatalf("expected duplicate IDs to be rejected")
+	}
+}
+
+func TestStampEditedItemsMarksChangedItems(t *testing.T) {
+	const then = "2024-01-01T00:00:00Z"
+	original := []config.MenuItem{
+		{ID: "10", Order: 20, Type: config.MenuItemText, Label: "Hello", UpdatedUTC: then},
+		{ID: "20", Order: 10, Type: config.MenuItemCommand, Label: "Run", Command: "run", Arguments: []string{}, UpdatedUTC: then},
+		{ID: "30", Order: 30, Type: config.MenuItemText, Label: "Bye", UpdatedUTC: then},
+	}
+	edited := []config.MenuItem{
+		{ID: "10", Order: 10, Type: config.MenuItemText, Label: "Hello", UpdatedUTC: then},
+		{ID: "20", Order: 20, Type: config.MenuItemCommand, Label: "Run", Command: "run", UpdatedUTC: then},
+		{ID: "30", Order: 30, Type: config.MenuItemText, Label: "Goodbye", UpdatedUTC: then},
+		{ID: "40", Order: 40, Type: config.MenuItemText, Label: "New", UpdatedUTC: then},
+	}
+
+	stampEditedItems(original, edited, "2025-06-01T00:00:00Z")
+	var stamped []string
+	for _, item := range edited {
+		if item.UpdatedUTC != then {
+			stamped = append(stamped, item.ID)
+		}
+	}
+	if !reflect.DeepEqual(stamped, []string{"30"}) {
+		t.Fatalf("expected only the relabelled item to be stamped, got %v", stamped)
+	}
+}
+
+func TestSettingsAndEditNormalizeTheSameWay(t *testing.T) {
+	var saved config.Settings
+	defer func(save saveFunc) { saveConfig = save }(saveConfig)
+	saveConfig = func(cfg *config.Config, _ outputFormat) error {
+		saved = cfg.Settings
+		return nil
+	}
+	if err := handleSettings(&config.Config{}, []string{"--locale", "de_DE.UTF-8", "--theme", "Auto", "--tooltip", " GoTray "}); err != nil {
+		t.Fatalf("handleSettings: %v", err)
+	}
+
+	edited := &config.Config{Settings: config.Settings{Locale: "de_DE.UTF-8", Theme: "Auto", Tooltip: " GoTray "}}
+	if err := validateEdited(edited); err != nil {
+		t.Fatalf("validateEdited: %v", err)
+	}
+	want := config.Settings{Locale: "de-de", Tooltip: "GoTray"}
+	if !reflect.DeepEqual(saved, want) || !reflect.DeepEqual(edited.Settings, want) {
+		t.Fatalf("expected both to save %+v, got settings %+v and edit %+v", want, saved, edited.Settings)
+	}
+
+	if err := validateEdited(&config.Config{Settings: config.Settings{Theme: "sepia"}}); err == nil {
+		t.Fatalf("expected an unknown theme to be rejected")
+	}
+}
@@ -0,0 +1,600 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/diff"
+	"github.com/example/gotray/internal/menu"
+)
+
+// outputFormat selects how CLI commands print their results.
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputYAML  outputFormat = "yaml"
+	outputCSV   outputFormat = "csv"
+)
+
+// outputFlag registers the --output flag shared by commands with
+// machine-readable results.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", string(outputTable), "output format: table, json, yaml, or csv")
+}
+
+func parseOutputFormat(value string) (outputFormat, error) {
+	switch format := outputFormat(strings.ToLower(strings.TrimSpace(value))); format {
+	case "", outputTable:
+		return outputTable, nil
+	case outputJSON, outputYAML, outputCSV:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format %q; expected table, json, yaml, or csv", value)
+}
+
+// itemRecord is the machine-readable form of a menu item in list output.
+// Field names are part of the CLI contract; add fields rather than rename.
+type itemRecord struct {
+	Position   int    `json:"position"`
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	ParentID   string `json:"parentId"`
+	Label      string `json:"label"`
+	CreatedUTC string `json:"createdUtc"`
+	UpdatedUTC string `json:"updatedUtc"`
+}
+
+var itemRecordColumns = []string{"position", "id", "type", "parentId", "label", "createdUtc", "updatedUtc"}
+
+func (r itemRecord) row() []string {
+	return []string{strconv.Itoa(r.Position), r.ID, r.Type, r.ParentID, r.Label, r.CreatedUTC, r.UpdatedUTC}
+}
+
+func newItemRecord(position int, item config.MenuItem) itemRecord {
+	return itemRecord{
+		Position:   position,
+		ID:         item.ID,
+		Type:       string(item.Type),
+		ParentID:   item.ParentID,
+		Label:      item.Label,
+		CreatedUTC: item.CreatedUTC,
+		UpdatedUTC: item.UpdatedUTC,
+	}
+}
+
+// itemSource tells where the items shown by a command came from.
+type itemSource string
+
+const (
+	sourceLocal itemSource = "local"
+	sourceTRMM  itemSource = "trmm"
+)
+
+func (s itemSource) description() string {
+	if s == sourceTRMM {
+		return "Tactical RMM"
+	}
+	return "local configuration"
+}
+
+// treeRecord is the machine-readable form of a menu item in tree output.
+type treeRecord struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Label    string       `json:"label"`
+	ParentID string       `json:"parentId"`
+	Source   itemSource   `json:"source"`
+	Disabled bool         `json:"disabled"`
+	Runtime  bool         `json:"runtime"`
+	Children []treeRecord `json:"children"`
+}
+
+var treeRecordColumns = []string{"depth", "id", "type", "label", "parentId", "source", "disabled", "runtime"}
+
+func newTreeRecords(nodes []menu.TreeNode, source itemSource) []treeRecord {
+	records := make([]treeRecord, 0, len(nodes))
+	for _, node := range nodes {
+		item := node.Item
+		records = append(records, treeRecord{
+			ID:       item.ID,
+			Type:     string(item.Type),
+			Label:    displayLabel(item),
+			ParentID: item.ParentID,
+			Source:   source,
+			Disabled: node.Disabled,
+			// Generators, script directories, results and tasks add
+			// entries the configuration does not list.
+			Runtime:  item.Type == config.MenuItemGenerator || item.Type == config.MenuItemResults || item.Type == config.MenuItemTasks || item.Directory != "",
+			Children: newTreeRecords(node.Children, source),
+		})
+	}
+	return records
+}
+
+// displayLabel returns the text the tray shows for item.
+func displayLabel(item config.MenuItem) string {
+	switch {
+	case item.Type == config.MenuItemDivider:
+		return "────"
+	case item.Label == "":
+		return "(no label)"
+	}
+	return item.Label
+}
+
+// writeTree prints tree output in a machine-readable format. CSV lists the
+// items depth first with their nesting depth.
+func writeTree(w io.Writer, format outputFormat, records []treeRecord) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(w, records)
+	case outputYAML:
+		return writeYAML(w, records)
+	}
+	var rows [][]string
+	var walk func(records []treeRecord, depth int)
+	walk = func(records []treeRecord, depth int) {
+		for _, record := range records {
+			rows = append(rows, []string{strconv.Itoa(depth), record.ID, record.Type, record.Label, record.ParentID, string(record.Source), strconv.FormatBool(record.Disabled), strconv.FormatBool(record.Runtime)})
+			walk(record.Children, depth+1)
+		}
+	}
+	walk(records, 0)
+	return writeCSV(w, treeRecordColumns, rows)
+}
+
+// itemRef identifies a related item in show output.
+type itemRef struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+func newItemRef(item config.MenuItem) itemRef {
+	return itemRef{ID: item.ID, Type: string(item.Type), Label: item.Label}
+}
+
+func (r itemRef) String() string {
+	if r.Label == "" {
+		return fmt.Sprintf("%s (%s)", r.ID, r.Type)
+	}
+	return fmt.Sprintf("%s (%s, id %s)", r.Label, r.Type, r.ID)
+}
+
+// itemDetail is the output of the show command: the complete item, the
+// chain of parents from the top level down, and its direct children in
+// tray order.
+type itemDetail struct {
+	Position int             `json:"position"`
+	Item     config.MenuItem `json:"item"`
+	Parents  []itemRef       `json:"parents"`
+	Children []itemRef       `json:"children"`
+	// MissingParent is set when the chain ends at a parent id that does
+	// not exist.
+	MissingParent string `json:"missingParent,omitempty"`
+}
+
+func newItemDetail(items []config.MenuItem, idx int) itemDetail {
+	item := items[idx]
+	detail := itemDetail{Position: idx + 1, Item: item, Parents: []itemRef{}, Children: []itemRef{}}
+
+	seen := map[string]bool{item.ID: true}
+	for parentID := item.ParentID; parentID != "" && !seen[parentID]; {
+		seen[parentID] = true
+		parentIdx := findItemIndexByID(items, parentID)
+		if parentIdx == -1 {
+			detail.MissingParent = parentID
+			break
+		}
+		parent := items[parentIdx]
+		detail.Parents = append([]itemRef{newItemRef(parent)}, detail.Parents...)
+		parentID = parent.ParentID
+	}
+
+	for _, child := range menu.Children(items, item.ID) {
+		detail.Children = append(detail.Children, newItemRef(child))
+	}
+	return detail
+}
+
+// itemFields lists the non-empty fields of item by their configuration
+// names, in declaration order, formatted for display.
+func itemFields(item config.MenuItem) [][2]string {
+	value := reflect.ValueOf(item)
+	fields := make([][2]string, 0, value.NumField())
+	for idx := 0; idx < value.NumField(); idx++ {
+		field := value.Field(idx)
+		if field.IsZero() {
+			continue
+		}
+		name, _, _ := strings.Cut(value.Type().Field(idx).Tag.Get("json"), ",")
+		switch field.Kind() {
+		case reflect.Map:
+			keys := make([]string, 0, field.Len())
+			for _, key := range field.MapKeys() {
+				keys = append(keys, key.String())
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fields = append(fields, [2]string{name + "." + key, field.MapIndex(reflect.ValueOf(key)).String()})
+			}
+			continue
+		case reflect.Slice:
+			if strs, ok := field.Interface().([]string); ok {
+				fields = append(fields, [2]string{name, strings.Join(strs, ", ")})
+				continue
+			}
+		}
+		if field.Kind() == reflect.String {
+			fields = append(fields, [2]string{name, field.String()})
+			continue
+		}
+		if encoded, err := json.Marshal(field.Interface()); err == nil {
+			fields = append(fields, [2]string{name, string(encoded)})
+		}
+	}
+	return fields
+}
+
+// changeRecord is the machine-readable form of a difference reported by
+// the diff command.
+type changeRecord struct {
+	Change       string   `json:"change"`
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	Label        string   `json:"label"`
+	Fields       []string `json:"fields,omitempty"`
+	FromParent   *string  `json:"fromParent,omitempty"`
+	ToParent     *string  `json:"toParent,omitempty"`
+	FromPosition int      `json:"fromPosition,omitempty"`
+	ToPosition   int      `json:"toPosition,omitempty"`
+}
+
+var changeRecordColumns = []string{"change", "id", "type", "label", "fields", "fromParent", "toParent", "fromPosition", "toPosition"}
+
+func newChangeRecord(change diff.Change) changeRecord {
+	record := changeRecord{
+		Change: string(change.Kind),
+		ID:     change.ID,
+		Type:   string(change.Type),
+		Label:  change.Label,
+		Fields: change.Fields,
+	}
+	if change.Kind == diff.Moved {
+		record.FromParent, record.ToParent = &change.FromParent, &change.ToParent
+		record.FromPosition, record.ToPosition = change.FromPosition, change.ToPosition
+	}
+	return record
+}
+
+func (r changeRecord) row() []string {
+	row := []string{r.Change, r.ID, r.Type, r.Label, strings.Join(r.Fields, " "), "", "", "", ""}
+	if r.FromParent != nil {
+		row[5], row[6] = *r.FromParent, *r.ToParent
+		row[7], row[8] = strconv.Itoa(r.FromPosition), strconv.Itoa(r.ToPosition)
+	}
+	return row
+}
+
+// describe renders the change for table output, with added and removed
+// describing items present on only one side.
+func (r changeRecord) describe(added, removed string) string {
+	label := r.Label
+	if label == "" {
+		label = "(no label)"
+	}
+	item := fmt.Sprintf("%s %s (%s)", r.ID, label, r.Type)
+	switch diff.ChangeKind(r.Change) {
+	case diff.Added:
+		return fmt.Sprintf("+ %s: %s", item, added)
+	case diff.Removed:
+		return fmt.Sprintf("- %s: %s", item, removed)
+	case diff.Moved:
+		return fmt.Sprintf("> %s: moved from %s to %s", item, describePlace(*r.FromParent, r.FromPosition), describePlace(*r.ToParent, r.ToPosition))
+	}
+	return fmt.Sprintf("~ %s: %s changed", item, strings.Join(r.Fields, ", "))
+}
+
+func describePlace(parent string, position int) string {
+	if parent == "" {
+		return fmt.Sprintf("top level position %d", position)
+	}
+	return fmt.Sprintf("position %d under %s", position, parent)
+}
+
+// writeChanges prints the result of the diff command.
+func writeChanges(w io.Writer, format outputFormat, records []changeRecord, source string) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(w, records)
+	case outputYAML:
+		return writeYAML(w, records)
+	case outputCSV:
+		rows := make([][]string, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, record.row())
+		}
+		return writeCSV(w, changeRecordColumns, rows)
+	}
+
+	if len(records) == 0 {
+		_, err := fmt.Fprintf(w, "No differences from %s\n", source)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%d differences from %s\n", len(records), source); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if _, err := fmt.Fprintln(w, record.describe("only in "+source, "only in the local configuration")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importResult describes the outcome of the import command: the commandResult
+// fields, counts of the changes made to the menu, and how duplicate IDs
+// were resolved.
+type importResult struct {
+	commandResult
+	Mode       string            `json:"mode"`
+	Added      int               `json:"added"`
+	Removed    int               `json:"removed"`
+	Moved      int               `json:"moved"`
+	Changed    int               `json:"changed"`
+	Kept       []string          `json:"kept,omitempty"`
+	Renumbered map[string]string `json:"renumbered,omitempty"`
+	Changes    []changeRecord    `json:"changes"`
+}
+
+var importResultColumns = []string{"action", "mode", "count", "added", "removed", "moved", "changed", "kept", "renumbered"}
+
+func newImportResult(mode menu.ImportMode, count int, changes []diff.Change, merged menu.ImportResult) importResult {
+	result := importResult{
+		commandResult: commandResult{Action: "imported", Count: count},
+		Mode:          string(mode),
+		Kept:          merged.Kept,
+		Changes:       make([]changeRecord, 0, len(changes)),
+	}
+	if len(merged.Renumbered) > 0 {
+		result.Renumbered = merged.Renumbered
+	}
+	for _, change := range changes {
+		switch change.Kind {
+		case diff.Added:
+			result.Added++
+		case diff.Removed:
+			result.Removed++
+		case diff.Moved:
+			result.Moved++
+		case diff.Changed:
+			result.Changed++
+		}
+		result.Changes = append(result.Changes, newChangeRecord(change))
+	}
+	return result
+}
+
+func (r importResult) row() []string {
+	renumbered := make([]string, 0, len(r.Renumbered))
+	for from, to := range r.Renumbered {
+		renumbered = append(renumbered, from+"="+to)
+	}
+	sort.Strings(renumbered)
+	return []string{
+		r.Action, r.Mode, strconv.Itoa(r.Count),
+		strconv.Itoa(r.Added), strconv.Itoa(r.Removed), strconv.Itoa(r.Moved), strconv.Itoa(r.Changed),
+		strings.Join(r.Kept, " "), strings.Join(renumbered, " "),
+	}
+}
+
+// summary lists the non-zero change counts, e.g. "2 added, 1 changed".
+func (r importResult) summary() string {
+	var parts []string
+	for _, count := range []struct {
+		n    int
+		verb string
+	}{{r.Added, "added"}, {r.Removed, "removed"}, {r.Moved, "moved"}, {r.Changed, "changed"}} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.n, count.verb))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeImportResult prints the outcome of an import with one line per
+// change for table output.
+func writeImportResult(w io.Writer, format outputFormat, result importResult) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(w, result)
+	case outputYAML:
+		return writeYAML(w, result)
+	case outputCSV:
+		return writeCSV(w, importResultColumns, [][]string{result.row()})
+	}
+
+	lines := []string{fmt.Sprintf("Imported %d menu items (%s): %s", result.Count, result.Mode, result.summary())}
+	for _, record := range result.Changes {
+		lines = append(lines, record.describe("added", "removed"))
+	}
+	for _, id := range result.Kept {
+		lines = append(lines, fmt.Sprintf("= %s: kept the existing item, the imported one was skipped", id))
+	}
+	renumbered := make([]string, 0, len(result.Renumbered))
+	for from := range result.Renumbered {
+		renumbered = append(renumbered, from)
+	}
+	sort.Strings(renumbered)
+	for _, from := range renumbered {
+		lines = append(lines, fmt.Sprintf("# %s: imported item added as %s", from, result.Renumbered[from]))
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// commandResult describes the outcome of a command that changes the
+// configuration. Count is set by commands affecting several items.
+type commandResult struct {
+	Action   string `json:"action"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Label    string `json:"label,omitempty"`
+	Position int    `json:"position,omitempty"`
+	Count    int    `json:"count"`
+}
+
+var commandResultColumns = []string{"action", "id", "type", "label", "position", "count"}
+
+func (r commandResult) row() []string {
+	position := ""
+	if r.Position > 0 {
+		position = strconv.Itoa(r.Position)
+	}
+	return []string{r.Action, r.ID, r.Type, r.Label, position, strconv.Itoa(r.Count)}
+}
+
+// itemResult builds the result for a command acting on a single item.
+func itemResult(action string, item config.MenuItem, position int) commandResult {
+	return commandResult{
+		Action:   action,
+		ID:       item.ID,
+		Type:     string(item.Type),
+		Label:    item.Label,
+		Position: position,
+		Count:    1,
+	}
+}
+
+// describe renders the result for table output, e.g.
+// "added 10.10 Dev tools (menu) at position 3".
+func (r commandResult) describe() string {
+	if r.ID == "" {
+		return fmt.Sprintf("%s %d menu items", r.Action, r.Count)
+	}
+	text := fmt.Sprintf("%s %s", r.Action, r.ID)
+	if r.Label != "" {
+		text += " " + r.Label
+	}
+	text += fmt.Sprintf(" (%s)", r.Type)
+	if r.Position > 0 {
+		text += fmt.Sprintf(" at position %d", r.Position)
+	}
+	return text
+}
+
+// writeApplyResults prints the results of an apply batch, one per
+// operation.
+func writeApplyResults(w io.Writer, format outputFormat, results []commandResult) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(w, results)
+	case outputYAML:
+		return writeYAML(w, results)
+	case outputCSV:
+		rows := make([][]string, 0, len(results))
+		for _, result := range results {
+			rows = append(rows, result.row())
+		}
+		return writeCSV(w, commandResultColumns, rows)
+	}
+
+	for idx, result := range results {
+		if _, err := fmt.Fprintf(w, "%d. %s\n", idx+1, result.describe()); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Applied %d operations\n", len(results))
+	return err
+}
+
+// writeRecords prints list output in a machine-readable format.
+func writeRecords(w io.Writer, format outputFormat, records []itemRecord) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(w, records)
+	case outputYAML:
+		return writeYAML(w, records)
+	}
+	rows := make([][]string, 0, len(records))
+	for _, record := range records {
+		rows = append(rows, record.row())
+	}
+	return writeCSV(w, itemRecordColumns, rows)
+}
+
+// writeResult prints result in format, or message for table output.
+func writeResult(w io.Writer, format outputFormat, result commandResult, message string) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(w, result)
+	case outputYAML:
+		return writeYAML(w, result)
+	case outputCSV:
+		return writeCSV(w, commandResultColumns, [][]string{result.row()})
+	}
+	_, err := fmt.Fprintln(w, message)
+	return err
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writeYAML prints value as YAML with the same field names and order as its
+// JSON form, so both formats share one set of struct tags.
+func writeYAML(w io.Writer, value any) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(payload, &node); err != nil {
+		return err
+	}
+	plainStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// plainStyle drops the JSON quoting and flow styles so the encoder picks
+// idiomatic YAML, quoting only where needed.
+func plainStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		plainStyle(child)
+	}
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
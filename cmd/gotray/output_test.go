@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func TestWriteRecordsUsesStableFieldNames(t *testing.T) {
+	records := []itemRecord{newItemRecord(1, config.MenuItem{ID: "10", Type: config.MenuItemText, Label: "Hello, world", ParentID: "root"})}
+
+	var out bytes.Buffer
+	if err := writeRecords(&out, outputCSV, records); err != nil {
+		t.Fatal(err)
+	}
+	want := "position,id,type,parentId,label,createdUtc,updatedUtc\n1,10,text,root,\"Hello, world\",,\n"
+	if out.String() != want {
+		t.Fatalf("unexpected csv output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := writeRecords(&out, outputJSON, records); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0]["id"] != "10" || decoded[0]["parentId"] != "root" || decoded[0]["position"] != float64(1) {
+		t.Fatalf("unexpected json output: %s", out.String())
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	result := itemResult("added", config.MenuItem{ID: "20", Type: config.MenuItemURL, Label: "Docs"}, 2)
+
+	var out bytes.Buffer
+	if err := writeResult(&out, outputYAML, result, "Added"); err != nil {
+		t.Fatal(err)
+	}
+	want := "action: added\nid: \"20\"\ntype: url\nlabel: Docs\nposition: 2\ncount: 1\n"
+	if out.String() != want {
+		t.Fatalf("unexpected yaml output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := writeResult(&out, outputTable, result, "Added menu item 20"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Added menu item 20\n" {
+		t.Fatalf("unexpected table output %q", out.String())
+	}
+
+	if _, err := parseOutputFormat("xml"); err == nil {
+		t.Fatalf("expected an unknown format to be rejected")
+	}
+}
+
+func TestNewItemDetailResolvesParentsAndChildren(t *testing.T) {
+	items := []config.MenuItem{
+		{ID: "10", Order: 10, Type: config.MenuItemGenerator, Label: "Servers"},
+		{ID: "10.10", Order: 10, ParentID: "10", Type: config.MenuItemMenu, Label: "Web"},
+		{ID: "10.10.1", Order: 10, ParentID: "10.10", Type: config.MenuItemCommand, Label: "Restart"},
+		{ID: "10.10.2", Order: 20, ParentID: "10.10", Type: config.MenuItemURL, Label: "Status"},
+		{ID: "20.1", ParentID: "20", Type: config.MenuItemText, Label: "Lost"},
+	}
+
+	detail := newItemDetail(items, 1)
+	if detail.Position != 2 || len(detail.Parents) != 1 || detail.Parents[0].ID != "10" {
+		t.Fatalf("unexpected parents %+v", detail.Parents)
+	}
+	if len(detail.Children) != 2 || detail.Children[0].ID != "10.10.2" || detail.Children[1].ID != "10.10.1" {
+		t.Fatalf("expected children in tray order, got %+v", detail.Children)
+	}
+
+	if orphan := newItemDetail(items, 4); orphan.MissingParent != "20" || len(orphan.Parents) != 0 {
+		t.Fatalf("expected the missing parent to be reported, got %+v", orphan)
+	}
+
+	fields := itemFields(config.MenuItem{ID: "30", Type: config.MenuItemCommand, Arguments: []string{"-f", "log"}, Labels: map[string]string{"de": "Protokoll"}})
+	want := [][2]string{{"id", "30"}, {"type", "command"}, {"arguments", "-f, log"}, {"labels.de", "Protokoll"}}
+	if len(fields) != len(want) {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	for idx := range want {
+		if fields[idx] != want[idx] {
+			t.Fatalf("field %d: got %v, want %v", idx, fields[idx], want[idx])
+		}
+	}
+}
@@ -0,0 +1,786 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/locale"
+	"github.com/example/gotray/internal/menu"
+)
+
+// tuiKey is a key press: a named key such as "up" or "enter", or a typed
+// rune when name is empty.
+type tuiKey struct {
+	name string
+	r    rune
+}
+
+type tuiMode int
+
+const (
+	tuiBrowse tuiMode = iota
+	tuiMoving
+	tuiForm
+	tuiConfirm
+	tuiPreview
+)
+
+// tuiRow is a line of the menu tree.
+type tuiRow struct {
+	node   menu.TreeNode
+	depth  int
+	orphan bool
+}
+
+// tuiField is an input of the add and edit forms. Choices turns the field
+// into a selection cycled with the arrow keys.
+type tuiField struct {
+	key     string
+	value   string
+	choices []string
+}
+
+type tuiItemForm struct {
+	title   string
+	op      string
+	id      string
+	fields  []tuiField
+	focus   int
+	initial map[string]string
+}
+
+// tuiModel is the state of the terminal UI. Keys are applied with handleKey
+// and the screen is drawn with render, so the model does not touch the
+// terminal itself.
+type tuiModel struct {
+	cfg       *config.Config
+	tag       string
+	rows      []tuiRow
+	selected  int
+	offset    int
+	collapsed map[string]bool
+	mode      tuiMode
+	form      *tuiItemForm
+	prompt    string
+	onConfirm func(key rune)
+	status    string
+	failed    bool
+	modified  bool
+	save      bool
+	quitting  bool
+}
+
+// tuiItemTypes are the types offered when adding an item.
+var tuiItemTypes = []string{
+	string(config.MenuItemText), string(config.MenuItemCommand), string(config.MenuItemWorkflow),
+	string(config.MenuItemURL), string(config.MenuItemHTTP), string(config.MenuItemWOL),
+	string(config.MenuItemMenu), string(config.MenuItemGenerator), string(config.MenuItemDivider),
+	string(config.MenuItemRefresh), string(config.MenuItemResults), string(config.MenuItemTasks),
+	string(config.MenuItemQuit),
+}
+
+// tuiFieldLabels names the form inputs; the keys are the flags of add and
+// update.
+var tuiFieldLabels = map[string]string{
+	"type":        "Type",
+	"label":       "Label",
+	"parent":      "Parent ID",
+	"description": "Description",
+	"command":     "Command",
+	"args":        "Arguments (comma-separated)",
+	"workdir":     "Working directory",
+	"terminal":    "Run in terminal (true/false)",
+	"timeout":     "Timeout (seconds)",
+	"steps":       "Steps (JSON)",
+	"url":         "URL",
+	"method":      "HTTP method",
+	"body":        "Request body",
+	"mac":         "MAC address",
+	"broadcast":   "Broadcast address",
+	"port":        "Port",
+	"directory":   "Script directory",
+	"source":      "Source file",
+	"interval":    "Interval (seconds)",
+}
+
+func newTUIModel(cfg *config.Config) *tuiModel {
+	m := &tuiModel{
+		cfg:       cfg,
+		tag:       locale.Detect(cfg.Settings.Locale),
+		collapsed: make(map[string]bool),
+	}
+	menu.EnsureSequentialOrder(&m.cfg.Items)
+	m.refresh("")
+	return m
+}
+
+// refresh rebuilds the visible rows and selects the item with id, or keeps
+// the current row when id is empty or no longer shown.
+func (m *tuiModel) refresh(id string) {
+	nodes, orphans := menu.BuildTree(m.cfg.Items, m.tag)
+	m.rows = m.rows[:0]
+	var add func(nodes []menu.TreeNode, depth int)
+	add = func(nodes []menu.TreeNode, depth int) {
+		for _, node := range nodes {
+			m.rows = append(m.rows, tuiRow{node: node, depth: depth})
+			if !m.collapsed[node.Item.ID] {
+				add(node.Children, depth+1)
+			}
+		}
+	}
+	add(nodes, 0)
+	for _, item := range orphans {
+		m.rows = append(m.rows, tuiRow{node: menu.TreeNode{Item: item, Disabled: true}, orphan: true})
+	}
+
+	if id != "" {
+		for idx, row := range m.rows {
+			if row.node.Item.ID == id {
+				m.selected = idx
+			}
+		}
+	}
+	m.selected = min(max(m.selected, 0), max(len(m.rows)-1, 0))
+}
+
+// current returns the stored, unlocalised item of the selected row.
+func (m *tuiModel) current() (config.MenuItem, bool) {
+	if len(m.rows) == 0 {
+		return config.MenuItem{}, false
+	}
+	idx := findItemIndexByID(m.cfg.Items, m.rows[m.selected].node.Item.ID)
+	if idx == -1 {
+		return config.MenuItem{}, false
+	}
+	return m.cfg.Items[idx], true
+}
+
+func (m *tuiModel) setStatus(failed bool, format string, args ...any) {
+	m.status = fmt.Sprintf(format, args...)
+	m.failed = failed
+}
+
+// handleKey applies a key press and reports whether the UI should exit.
+func (m *tuiModel) handleKey(key tuiKey) bool {
+	if m.mode != tuiForm {
+		m.status, m.failed = "", false
+	}
+	switch m.mode {
+	case tuiMoving:
+		m.handleMoveKey(key)
+	case tuiForm:
+		m.handleFormKey(key)
+	case tuiConfirm:
+		if key.name == "esc" || key.name == "ctrl+c" {
+			m.mode = tuiBrowse
+			return false
+		}
+		confirm := m.onConfirm
+		m.mode = tuiBrowse
+		confirm(key.r)
+	case tuiPreview:
+		m.mode = tuiBrowse
+	default:
+		m.handleBrowseKey(key)
+	}
+	return m.quitting
+}
+
+func (m *tuiModel) handleBrowseKey(key tuiKey) {
+	switch key.name {
+	case "up":
+		m.selected = max(m.selected-1, 0)
+	case "down":
+		m.selected = min(m.selected+1, max(len(m.rows)-1, 0))
+	case "home":
+		m.selected = 0
+	case "end":
+		m.selected = max(len(m.rows)-1, 0)
+	case "pgup":
+		m.selected = max(m.selected-10, 0)
+	case "pgdn":
+		m.selected = min(m.selected+10, max(len(m.rows)-1, 0))
+	case "left":
+		m.collapse()
+	case "right":
+		if item, ok := m.current(); ok && m.collapsed[item.ID] {
+			delete(m.collapsed, item.ID)
+			m.refresh(item.ID)
+		}
+	case "enter":
+		m.preview()
+	case "delete":
+		m.confirmDelete()
+	case "ctrl+c":
+		m.quit()
+	case "ctrl+s":
+		m.save, m.quitting = true, true
+	}
+
+	switch key.r {
+	case 'k':
+		m.handleBrowseKey(tuiKey{name: "up"})
+	case 'j':
+		m.handleBrowseKey(tuiKey{name: "down"})
+	case 'a':
+		m.openAddForm()
+	case 'e':
+		m.openEditForm()
+	case 'm':
+		if _, ok := m.current(); ok && !m.rows[m.selected].orphan {
+			m.mode = tuiMoving
+		}
+	case 'd':
+		m.confirmDelete()
+	case 'p':
+		m.preview()
+	case 's':
+		m.save, m.quitting = true, true
+	case 'q':
+		m.quit()
+	}
+}
+
+// collapse hides the children of the selected submenu, or selects the
+// parent of an item that has none shown.
+func (m *tuiModel) collapse() {
+	item, ok := m.current()
+	if !ok {
+		return
+	}
+	if len(m.rows[m.selected].node.Children) > 0 && !m.collapsed[item.ID] {
+		m.collapsed[item.ID] = true
+		m.refresh(item.ID)
+		return
+	}
+	if item.ParentID != "" {
+		m.refresh(item.ParentID)
+	}
+}
+
+// quit exits at once when nothing changed and otherwise asks whether to
+// save first.
+func (m *tuiModel) quit() {
+	if !m.modified {
+		m.quitting = true
+		return
+	}
+	m.ask("Save changes before quitting? (y)es, (n)o, Esc to continue editing", func(key rune) {
+		switch key {
+		case 'y', 'Y':
+			m.save, m.quitting = true, true
+		case 'n', 'N':
+			m.quitting = true
+		}
+	})
+}
+
+func (m *tuiModel) ask(prompt string, confirm func(key rune)) {
+	m.mode = tuiConfirm
+	m.prompt = prompt
+	m.onConfirm = confirm
+}
+
+func (m *tuiModel) preview() {
+	if _, ok := m.current(); ok {
+		m.mode = tuiPreview
+	}
+}
+
+func (m *tuiModel) confirmDelete() {
+	item, ok := m.current()
+	if !ok {
+		return
+	}
+	subtree := menu.Subtree(m.cfg.Items, item.ID)
+	prompt := fmt.Sprintf("Delete %s? (y/n)", itemName(item))
+	if len(subtree) > 1 {
+		prompt = fmt.Sprintf("Delete %s and %d nested items? (y/n)", itemName(item), len(subtree)-1)
+	}
+	m.ask(prompt, func(key rune) {
+		if key != 'y' && key != 'Y' {
+			return
+		}
+		if _, err := applyOperations(m.cfg, []operation{{"op": "delete", "id": item.ID, "recursive": true}}); err != nil {
+			m.setStatus(true, "%v", operationCause(err))
+			return
+		}
+		m.modified = true
+		m.refresh("")
+		m.setStatus(false, "Deleted %s", itemName(item))
+	})
+}
+
+// handleMoveKey moves the selected item: up and down among its siblings,
+// left out of its submenu, and right into the submenu above it.
+func (m *tuiModel) handleMoveKey(key tuiKey) {
+	item, ok := m.current()
+	if !ok {
+		m.mode = tuiBrowse
+		return
+	}
+	siblings := menu.Children(m.cfg.Items, item.ParentID)
+	position := 0
+	for idx, sibling := range siblings {
+		if sibling.ID == item.ID {
+			position = idx
+		}
+	}
+
+	var err error
+	switch {
+	case key.name == "up" || key.r == 'k':
+		if position > 0 {
+			err = menu.Reposition(m.cfg.Items, item.ID, item.ParentID, position-1)
+		}
+	case key.name == "down" || key.r == 'j':
+		if position < len(siblings)-1 {
+			err = menu.Reposition(m.cfg.Items, item.ID, item.ParentID, position+1)
+		}
+	case key.name == "left" || key.r == 'h':
+		if item.ParentID == "" {
+			return
+		}
+		parentIdx := findItemIndexByID(m.cfg.Items, item.ParentID)
+		if parentIdx == -1 {
+			return
+		}
+		parent := m.cfg.Items[parentIdx]
+		parentPosition := 0
+		for idx, sibling := range menu.Children(m.cfg.Items, parent.ParentID) {
+			if sibling.ID == parent.ID {
+				parentPosition = idx
+			}
+		}
+		err = menu.Reposition(m.cfg.Items, item.ID, parent.ParentID, parentPosition+1)
+	case key.name == "right" || key.r == 'l':
+		if position == 0 {
+			m.setStatus(true, "No submenu above %s", itemName(item))
+			return
+		}
+		target := siblings[position-1]
+		err = menu.Reposition(m.cfg.Items, item.ID, target.ID, len(menu.Children(m.cfg.Items, target.ID)))
+		delete(m.collapsed, target.ID)
+	case key.name == "enter" || key.name == "esc" || key.r == 'm':
+		m.mode = tuiBrowse
+		return
+	default:
+		return
+	}
+	if err != nil {
+		m.setStatus(true, "%v", err)
+		return
+	}
+
+	idx := findItemIndexByID(m.cfg.Items, item.ID)
+	if m.cfg.Items[idx].ParentID != item.ParentID || m.cfg.Items[idx].Order != item.Order {
+		m.cfg.Items[idx].UpdatedUTC = time.Now().UTC().Format(time.RFC3339)
+		m.modified = true
+	}
+	m.refresh(item.ID)
+}
+
+// formKeys lists the inputs shown for an item type after type, label, and
+// parent.
+func formKeys(itemType config.MenuItemType) []string {
+	switch itemType {
+	case config.MenuItemCommand:
+		return []string{"command", "args", "workdir", "terminal", "timeout", "description"}
+	case config.MenuItemWorkflow:
+		return []string{"steps", "timeout", "description"}
+	case config.MenuItemURL:
+		return []string{"url", "description"}
+	case config.MenuItemHTTP:
+		return []string{"url", "method", "body", "timeout", "description"}
+	case config.MenuItemWOL:
+		return []string{"mac", "broadcast", "port", "description"}
+	case config.MenuItemMenu:
+		return []string{"directory", "description"}
+	case config.MenuItemGenerator:
+		return []string{"command", "args", "source", "interval", "description"}
+	case config.MenuItemDivider:
+		return nil
+	}
+	return []string{"description"}
+}
+
+// buildFields lays out the inputs for the selected type, keeping the values
+// already entered for inputs that remain.
+func (f *tuiItemForm) buildFields(itemType string) {
+	values := make(map[string]string, len(f.fields))
+	for _, field := range f.fields {
+		values[field.key] = field.value
+	}
+	keys := []string{"label"}
+	if itemType == string(config.MenuItemDivider) {
+		keys = nil
+	}
+	if f.op == "add" {
+		keys = append(keys, "parent")
+	}
+	keys = append(keys, formKeys(config.MenuItemType(itemType))...)
+
+	fields := []tuiField{{key: "type", value: itemType, choices: tuiItemTypes}}
+	for _, key := range keys {
+		fields = append(fields, tuiField{key: key, value: values[key]})
+	}
+	f.fields = fields
+	f.focus = min(f.focus, len(fields)-1)
+}
+
+func (m *tuiModel) openAddForm() {
+	parentID := ""
+	itemType := string(config.MenuItemCommand)
+	if item, ok := m.current(); ok && !m.rows[m.selected].orphan {
+		parentID = item.ParentID
+		if item.Type == config.MenuItemMenu || item.Type == config.MenuItemGenerator {
+			parentID = item.ID
+		}
+	}
+	form := &tuiItemForm{title: "Add menu item", op: "add", fields: []tuiField{{key: "parent", value: parentID}}}
+	form.buildFields(itemType)
+	form.focus = 1
+	m.form = form
+	m.mode = tuiForm
+	m.status = ""
+}
+
+func (m *tuiModel) openEditForm() {
+	item, ok := m.current()
+	if !ok {
+		return
+	}
+	values := map[string]string{
+		"type":        string(item.Type),
+		"label":       item.Label,
+		"description": item.Description,
+		"command":     item.Command,
+		"args":        strings.Join(item.Arguments, ","),
+		"workdir":     item.WorkingDir,
+		"url":         item.URL,
+		"method":      item.Method,
+		"body":        item.Body,
+		"mac":         item.MAC,
+		"broadcast":   item.Broadcast,
+		"directory":   item.Directory,
+		"source":      item.Source,
+		"terminal":    strconv.FormatBool(item.Terminal),
+		"timeout":     formatSeconds(item.TimeoutSeconds),
+		"port":        formatSeconds(item.Port),
+		"interval":    formatSeconds(item.IntervalSeconds),
+	}
+	if len(item.Steps) > 0 {
+		if steps, err := json.Marshal(item.Steps); err == nil {
+			values["steps"] = string(steps)
+		}
+	}
+
+	form := &tuiItemForm{title: "Edit " + itemName(item), op: "update", id: item.ID, initial: values}
+	for key, value := range values {
+		form.fields = append(form.fields, tuiField{key: key, value: value})
+	}
+	form.buildFields(string(item.Type))
+	form.focus = min(1, len(form.fields)-1)
+	m.form = form
+	m.mode = tuiForm
+	m.status = ""
+}
+
+func formatSeconds(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+func (m *tuiModel) handleFormKey(key tuiKey) {
+	form := m.form
+	field := &form.fields[form.focus]
+	switch key.name {
+	case "esc", "ctrl+c":
+		m.mode = tuiBrowse
+		m.form = nil
+		m.status = ""
+		return
+	case "up", "backtab":
+		form.focus = (form.focus + len(form.fields) - 1) % len(form.fields)
+	case "down", "tab":
+		form.focus = (form.focus + 1) % len(form.fields)
+	case "left", "right":
+		if len(field.choices) > 0 {
+			step := 1
+			if key.name == "left" {
+				step = len(field.choices) - 1
+			}
+			current := 0
+			for idx, choice := range field.choices {
+				if choice == field.value {
+					current = idx
+				}
+			}
+			form.buildFields(field.choices[(current+step)%len(field.choices)])
+		}
+	case "backspace":
+		if len(field.choices) == 0 && field.value != "" {
+			_, size := utf8.DecodeLastRuneInString(field.value)
+			field.value = field.value[:len(field.value)-size]
+		}
+	case "enter":
+		if form.focus < len(form.fields)-1 {
+			form.focus++
+			return
+		}
+		m.submitForm()
+	case "ctrl+s":
+		m.submitForm()
+	case "":
+		if len(field.choices) == 0 && key.r >= ' ' {
+			field.value += string(key.r)
+		}
+	}
+}
+
+// submitForm runs the form as an add or update operation, so the same flag
+// handling and validation apply as on the command line.
+func (m *tuiModel) submitForm() {
+	form := m.form
+	op := operation{"op": form.op}
+	for _, field := range form.fields {
+		value := strings.TrimSpace(field.value)
+		if form.op == "update" {
+			if value == strings.TrimSpace(form.initial[field.key]) {
+				continue
+			}
+			switch field.key {
+			case "timeout", "port", "interval":
+				if value == "" {
+					value = "0"
+				}
+			}
+		} else if value == "" {
+			continue
+		}
+		op[field.key] = value
+	}
+	if form.op == "update" {
+		if len(op) == 1 {
+			m.mode = tuiBrowse
+			m.form = nil
+			m.setStatus(false, "No changes")
+			return
+		}
+		op["id"] = form.id
+	}
+
+	results, err := applyOperations(m.cfg, []operation{op})
+	if err != nil {
+		// Keep the form open so the input can be corrected.
+		m.setStatus(true, "%v", operationCause(err))
+		return
+	}
+	m.mode = tuiBrowse
+	m.form = nil
+	m.modified = true
+	m.refresh(results[0].ID)
+	m.setStatus(false, "%s %s", strings.ToUpper(results[0].Action[:1])+results[0].Action[1:], results[0].ID)
+}
+
+// operationCause returns the error of the command behind a failed
+// operation, since the TUI runs one operation at a time and its position in
+// the batch means nothing to the user.
+func operationCause(err error) error {
+	var opErr *operationError
+	if errors.As(err, &opErr) {
+		return opErr.err
+	}
+	return err
+}
+
+func itemName(item config.MenuItem) string {
+	return itemRef{ID: item.ID, Type: string(item.Type), Label: item.Label}.String()
+}
+
+// render draws the screen as width-limited lines. The selected row and the
+// focused input are highlighted with reverse video.
+func (m *tuiModel) render(width, height int) []string {
+	width, height = max(width, 20), max(height, 8)
+	title := " GoTray menu editor"
+	info := fmt.Sprintf("%d items ", len(m.cfg.Items))
+	if m.modified {
+		info = "modified · " + info
+	}
+	lines := []string{fitLine(title+strings.Repeat(" ", max(width-utf8.RuneCountInString(title)-utf8.RuneCountInString(info), 1))+info, width)}
+
+	var pane []string
+	switch m.mode {
+	case tuiPreview:
+		pane = m.previewLines()
+	case tuiForm:
+		pane = m.formLines(width)
+	default:
+		pane = m.detailLines()
+	}
+
+	treeHeight := height - len(lines) - len(pane) - 3
+	if m.mode == tuiPreview {
+		treeHeight = 0
+	}
+	if treeHeight > 0 {
+		lines = append(lines, m.treeLines(width, treeHeight)...)
+	}
+	lines = append(lines, strings.Repeat("─", width))
+	lines = append(lines, pane...)
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+	lines = lines[:height-2]
+
+	status := m.status
+	if m.mode == tuiConfirm {
+		status = m.prompt
+	}
+	if m.failed && status != "" {
+		status = "\x1b[31m" + fitLine(status, width) + "\x1b[0m"
+	}
+	lines = append(lines, status, fitLine(m.helpLine(), width))
+	for idx, line := range lines {
+		if !strings.Contains(line, "\x1b[") {
+			lines[idx] = fitLine(line, width)
+		}
+	}
+	return lines
+}
+
+func (m *tuiModel) treeLines(width, height int) []string {
+	if len(m.rows) == 0 {
+		return []string{"  No menu items. Press a to add one."}
+	}
+	if m.selected < m.offset {
+		m.offset = m.selected
+	}
+	if m.selected >= m.offset+height {
+		m.offset = m.selected - height + 1
+	}
+	m.offset = min(m.offset, max(len(m.rows)-height, 0))
+
+	var lines []string
+	for idx := m.offset; idx < len(m.rows) && len(lines) < height; idx++ {
+		row := m.rows[idx]
+		item := row.node.Item
+		marker := "  "
+		if item.Type == config.MenuItemMenu || item.Type == config.MenuItemGenerator {
+			marker = "▾ "
+			if m.collapsed[item.ID] {
+				marker = "▸ "
+			}
+		}
+		text := strings.Repeat("  ", row.depth) + marker + displayLabel(item) + fmt.Sprintf("  (%s, id %s)", item.Type, item.ID)
+		if row.orphan {
+			text = "  ! " + displayLabel(item) + fmt.Sprintf("  (%s, id %s, unreachable)", item.Type, item.ID)
+		} else if row.node.Disabled {
+			text += " disabled"
+		}
+		line := fitLine(" "+text, width)
+		if idx == m.selected {
+			if m.mode == tuiMoving {
+				line = fitLine("⇅"+text, width)
+			}
+			line = "\x1b[7m" + line + strings.Repeat(" ", max(width-utf8.RuneCountInString(line), 0)) + "\x1b[0m"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// detailLines summarises the selected item below the tree.
+func (m *tuiModel) detailLines() []string {
+	item, ok := m.current()
+	if !ok {
+		return nil
+	}
+	var lines []string
+	for _, field := range itemFields(item) {
+		switch field[0] {
+		case "id", "order", "type", "label", "parentId", "createdUtc", "updatedUtc":
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(" %-16s %s", field[0], field[1]))
+		if len(lines) == 4 {
+			break
+		}
+	}
+	return append([]string{" " + itemName(item)}, lines...)
+}
+
+// previewLines shows every field of the selected item with its place in the
+// menu, like the show command.
+func (m *tuiModel) previewLines() []string {
+	item, ok := m.current()
+	if !ok {
+		return nil
+	}
+	detail := newItemDetail(m.cfg.Items, findItemIndexByID(m.cfg.Items, item.ID))
+	lines := []string{" " + itemName(item), ""}
+	for _, field := range itemFields(item) {
+		lines = append(lines, fmt.Sprintf(" %-16s %s", field[0], field[1]))
+	}
+	if len(detail.Parents) > 0 {
+		parents := make([]string, 0, len(detail.Parents))
+		for _, parent := range detail.Parents {
+			parents = append(parents, parent.String())
+		}
+		lines = append(lines, fmt.Sprintf(" %-16s %s", "parent chain", strings.Join(parents, " > ")))
+	}
+	for _, child := range detail.Children {
+		lines = append(lines, fmt.Sprintf(" %-16s %s", "child", child.String()))
+	}
+	return lines
+}
+
+func (m *tuiModel) formLines(width int) []string {
+	lines := []string{" " + m.form.title}
+	for idx, field := range m.form.fields {
+		value := field.value
+		if len(field.choices) > 0 {
+			value = "‹ " + value + " ›"
+		}
+		line := fitLine(fmt.Sprintf("   %-30s %s", tuiFieldLabels[field.key], value), width)
+		if idx == m.form.focus {
+			if len(field.choices) == 0 {
+				line = fitLine(fmt.Sprintf(" › %-30s %s_", tuiFieldLabels[field.key], value), width)
+			}
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (m *tuiModel) helpLine() string {
+	switch m.mode {
+	case tuiMoving:
+		return " ↑↓ reorder  ← out of submenu  → into submenu above  Enter done"
+	case tuiForm:
+		return " Tab/↑↓ field  ←→ type  Enter next/submit  Ctrl+S submit  Esc cancel"
+	case tuiConfirm:
+		return " y/n  Esc cancel"
+	case tuiPreview:
+		return " Any key to return"
+	}
+	return " ↑↓ select  ←→ fold  a add  e edit  m move  d delete  p preview  s save  q quit"
+}
+
+// fitLine cuts line to width runes.
+func fitLine(line string, width int) string {
+	if utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:max(width-1, 0)]) + "…"
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// enableTerminalSequences is a no-op where terminals interpret escape
+// sequences by default.
+func enableTerminalSequences(*os.File) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func handleTUI(cfg *config.Config, args []string) error {
+	fs := newFlagSet("tui")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return errors.New("tui requires an interactive terminal")
+	}
+
+	model := newTUIModel(cfg)
+	if err := runTUI(model, in, out); err != nil {
+		return err
+	}
+
+	switch {
+	case model.save && model.modified:
+		if err := saveConfig(cfg, outputTable); err != nil {
+			return err
+		}
+		fmt.Println("Saved configuration")
+	case model.modified:
+		fmt.Println("Changes discarded")
+	}
+	return nil
+}
+
+// runTUI switches the terminal to raw mode on the alternate screen and
+// redraws the model after every key until it quits.
+func runTUI(model *tuiModel, in, out int) error {
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return fmt.Errorf("prepare terminal: %w", err)
+	}
+	defer term.Restore(in, state)
+	restore, err := enableTerminalSequences(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("prepare terminal: %w", err)
+	}
+	defer restore()
+
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	keys := bufio.NewReader(os.Stdin)
+	for {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			width, height = 80, 24
+		}
+		lines := model.render(width, height)
+		fmt.Fprint(os.Stdout, "\x1b[H"+strings.Join(lines, "\x1b[K\r\n")+"\x1b[K\x1b[J")
+
+		key, err := readKey(keys)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if model.handleKey(key) {
+			return nil
+		}
+	}
+}
+
+// readKey decodes the next key press, including the escape sequences sent
+// for arrow and editing keys.
+func readKey(r *bufio.Reader) (tuiKey, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return tuiKey{}, err
+	}
+	switch b {
+	case '\r', '\n':
+		return tuiKey{name: "enter"}, nil
+	case '\t':
+		return tuiKey{name: "tab"}, nil
+	case 0x7f, 0x08:
+		return tuiKey{name: "backspace"}, nil
+	case 0x03:
+		return tuiKey{name: "ctrl+c"}, nil
+	case 0x13:
+		return tuiKey{name: "ctrl+s"}, nil
+	case 0x1b:
+		return readEscape(r)
+	}
+	if b < utf8.RuneSelf {
+		return tuiKey{r: rune(b)}, nil
+	}
+	if err := r.UnreadByte(); err != nil {
+		return tuiKey{}, err
+	}
+	ch, _, err := r.ReadRune()
+	return tuiKey{r: ch}, err
+}
+
+// readEscape decodes the rest of a CSI or SS3 sequence. A lone escape
+// without a following sequence is the Esc key.
+func readEscape(r *bufio.Reader) (tuiKey, error) {
+	if r.Buffered() == 0 {
+		return tuiKey{name: "esc"}, nil
+	}
+	introducer, err := r.ReadByte()
+	if err != nil {
+		return tuiKey{}, err
+	}
+	if introducer != '[' && introducer != 'O' {
+		return tuiKey{name: "esc"}, nil
+	}
+
+	var params []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return tuiKey{}, err
+		}
+		if b >= 0x40 && b <= 0x7e {
+			return tuiKey{name: escapeKeyName(b, string(params))}, nil
+		}
+		params = append(params, b)
+	}
+}
+
+func escapeKeyName(final byte, params string) string {
+	switch final {
+	case 'A':
+		return "up"
+	case 'B':
+		return "down"
+	case 'C':
+		return "right"
+	case 'D':
+		return "left"
+	case 'H':
+		return "home"
+	case 'F':
+		return "end"
+	case 'Z':
+		return "backtab"
+	case '~':
+		code, _, _ := strings.Cut(params, ";")
+		switch code {
+		case "1", "7":
+			return "home"
+		case "4", "8":
+			return "end"
+		case "3":
+			return "delete"
+		case "5":
+			return "pgup"
+		case "6":
+			return "pgdn"
+		}
+	}
+	return "unknown"
+}
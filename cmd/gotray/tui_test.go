@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func tuiFixture() *tuiModel {
+	return newTUIModel(&config.Config{Items: []config.MenuItem{
+		{ID: "10", Order: 30, Type: config.MenuItemGenerator, Label: "Hosts", Command: "hosts"},
+		{ID: "10.1", Order: 10, ParentID: "10", Type: config.MenuItemCommand, Label: "Ping", Command: "ping"},
+		{ID: "20", Order: 20, Type: config.MenuItemURL, Label: "Docs", URL: "https://example.com"},
+		{ID: "30", Order: 10, Type: config.MenuItemQuit, Label: "Quit"},
+	}})
+}
+
+func typeKeys(m *tuiModel, text string) {
+	for _, r := range text {
+		m.handleKey(tuiKey{r: r})
+	}
+}
+
+func selectedID(m *tuiModel) string {
+	item, _ := m.current()
+	return item.ID
+}
+
+func TestTUIAddsItemBelowSelectedSubmenu(t *testing.T) {
+	m := tuiFixture()
+	typeKeys(m, "a")
+	if m.mode != tuiForm || m.form.fields[2].key != "parent" || m.form.fields[2].value != "10" {
+		t.Fatalf("expected an add form below 10, got %+v", m.form)
+	}
+
+	typeKeys(m, "Trace")
+	m.handleKey(tuiKey{name: "enter"})
+	m.handleKey(tuiKey{name: "enter"})
+	m.handleKey(tuiKey{name: "ctrl+s"})
+	if m.mode != tuiForm || !m.failed || strings.HasPrefix(m.status, "operation") {
+		t.Fatalf("expected the form to stay open with the command's error, status %q", m.status)
+	}
+
+	m.form.focus = 3
+	typeKeys(m, "traceroute")
+	m.handleKey(tuiKey{name: "ctrl+s"})
+	if m.mode != tuiBrowse || !m.modified {
+		t.Fatalf("expected the item to be added, status %q", m.status)
+	}
+	if got := selectedID(m); got != "10.2" {
+		t.Fatalf("expected the new item to be selected, got %q", got)
+	}
+	if m.cfg.Items[findItemIndexByID(m.cfg.Items, "10.2")].Command != "traceroute" {
+		t.Fatalf("unexpected items %+v", m.cfg.Items)
+	}
+}
+
+func TestTUIMovesItemAcrossParents(t *testing.T) {
+	m := tuiFixture()
+	m.handleKey(tuiKey{name: "end"})
+	if got := selectedID(m); got != "30" {
+		t.Fatalf("expected the last row to be selected, got %q", got)
+	}
+
+	typeKeys(m, "m")
+	m.handleKey(tuiKey{name: "up"})
+	m.handleKey(tuiKey{name: "up"})
+	m.handleKey(tuiKey{name: "down"})
+	m.handleKey(tuiKey{name: "right"})
+	if got := menuOrder(m); got != "10 10.1 30 20" {
+		t.Fatalf("expected the item inside Hosts, got %q", got)
+	}
+
+	m.handleKey(tuiKey{name: "up"})
+	m.handleKey(tuiKey{name: "left"})
+	m.handleKey(tuiKey{name: "enter"})
+	if got := menuOrder(m); got != "10 10.1 30 20" || m.cfg.Items[findItemIndexByID(m.cfg.Items, "30")].ParentID != "" {
+		t.Fatalf("expected the item after Hosts again, got %q", got)
+	}
+	if m.mode != tuiBrowse || !m.modified {
+		t.Fatalf("expected move mode to end with changes, mode %d", m.mode)
+	}
+
+	m.handleKey(tuiKey{name: "home"})
+	typeKeys(m, "m")
+	m.handleKey(tuiKey{name: "right"})
+	if !m.failed {
+		t.Fatalf("expected the first item to have no submenu above it")
+	}
+}
+
+func menuOrder(m *tuiModel) string {
+	ids := make([]string, 0, len(m.rows))
+	for _, row := range m.rows {
+		ids = append(ids, row.node.Item.ID)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestTUIDeletesSubtreeAfterConfirmation(t *testing.T) {
+	m := tuiFixture()
+	typeKeys(m, "d")
+	if m.mode != tuiConfirm || !strings.Contains(m.prompt, "1 nested item") {
+		t.Fatalf("unexpected prompt %q", m.prompt)
+	}
+	typeKeys(m, "n")
+	if len(m.cfg.Items) != 4 {
+		t.Fatalf("expected nothing to be deleted")
+	}
+
+	typeKeys(m, "dy")
+	if got := menuOrder(m); got != "20 30" || !m.modified {
+		t.Fatalf("expected Hosts and its child to be deleted, got %q", got)
+	}
+
+	if m.handleKey(tuiKey{r: 'q'}) || m.mode != tuiConfirm {
+		t.Fatalf("expected quitting with changes to ask first")
+	}
+	if !m.handleKey(tuiKey{r: 'n'}) || m.save {
+		t.Fatalf("expected to quit without saving")
+	}
+}
+
+func TestReadKeyDecodesEscapeSequences(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\x1b[A\x1b[5~\x1bOHxé\r\x7f"))
+	want := []tuiKey{{name: "up"}, {name: "pgup"}, {name: "home"}, {r: 'x'}, {r: 'é'}, {name: "enter"}, {name: "backspace"}}
+	for _, expected := range want {
+		key, err := readKey(r)
+		if err != nil {
+			t.Fatalf("readKey: %v", err)
+		}
+		if key != expected {
+			t.Fatalf("expected %+v, got %+v", expected, key)
+		}
+	}
+}
@@ -5,6 +5,8 @@ go 1.24.3
 require (
 	github.com/getlantern/systray v1.2.2
 	golang.org/x/sys v0.37.0
+	golang.org/x/term v0.36.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/example/gotray/internal/logging"
@@ -21,13 +22,19 @@ const (
 type MenuItemType string
 
 const (
-	MenuItemText    MenuItemType = "text"
-	MenuItemDivider MenuItemType = "divider"
-	MenuItemCommand MenuItemType = "command"
-	MenuItemURL     MenuItemType = "url"
-	MenuItemMenu    MenuItemType = "menu"
-	MenuItemQuit    MenuItemType = "quit"
-	MenuItemRefresh MenuItemType = "refresh"
+	MenuItemText      MenuItemType = "text"
+	MenuItemDivider   MenuItemType = "divider"
+	MenuItemCommand   MenuItemType = "command"
+	MenuItemURL       MenuItemType = "url"
+	MenuItemMenu      MenuItemType = "menu"
+	MenuItemQuit      MenuItemType = "quit"
+	MenuItemRefresh   MenuItemType = "refresh"
+	MenuItemResults   MenuItemType = "results"
+	MenuItemTasks     MenuItemType = "tasks"
+	MenuItemWorkflow  MenuItemType = "workflow"
+	MenuItemHTTP      MenuItemType = "http"
+	MenuItemWOL       MenuItemType = "wol"
+	MenuItemGenerator MenuItemType = "generator"
 )
 
 // MenuItem represents a single menu entry in the tray.
@@ -42,13 +49,100 @@ type MenuItem struct {
 	URL         string       `json:"url,omitempty"`
 	Description string       `json:"description,omitempty"`
 	ParentID    string       `json:"parentId,omitempty"`
-	CreatedUTC  string       `json:"createdUtc"`
-	UpdatedUTC  string       `json:"updatedUtc"`
+	// Labels and Descriptions hold translations keyed by locale, such as
+	// "de" or "pt-BR". Label and Description are used when no entry matches.
+	Labels       map[string]string `json:"labels,omitempty"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+	// Icon is an optional image file shown next to the item's label.
+	Icon string `json:"icon,omitempty"`
+	// Directory populates a menu item with a command for each executable
+	// file it contains.
+	Directory string `json:"directory,omitempty"`
+	// TimeoutSeconds stops a running command after the given duration.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// SingleInstance prevents relaunching a command while it is still running.
+	SingleInstance bool `json:"singleInstance,omitempty"`
+	// Detach keeps the command running after the tray exits instead of
+	// stopping it on quit.
+	Detach bool `json:"detach,omitempty"`
+	// Terminal runs the command inside the user's terminal emulator.
+	Terminal bool `json:"terminal,omitempty"`
+	// KeepOpen leaves the terminal window open after the command exits.
+	KeepOpen bool `json:"keepOpen,omitempty"`
+	// Steps lists the commands executed in order by workflow items.
+	Steps []WorkflowStep `json:"steps,omitempty"`
+	// Method, Headers, and Body describe the request sent by http items.
+	// Header values and the body are rendered as Go templates.
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// MAC, Broadcast, and Port describe the magic packet sent by wol items.
+	MAC       string `json:"mac,omitempty"`
+	Broadcast string `json:"broadcast,omitempty"`
+	Port      int    `json:"port,omitempty"`
+	// Source names a file whose JSON contents provide the children of a
+	// generator item; otherwise the item's command output is used.
+	Source string `json:"source,omitempty"`
+	// IntervalSeconds re-evaluates a generator on a schedule in addition to
+	// every menu refresh.
+	IntervalSeconds int    `json:"intervalSeconds,omitempty"`
+	CreatedUTC      string `json:"createdUtc"`
+	UpdatedUTC      string `json:"updatedUtc"`
+}
+
+// WorkflowStep is a single command executed as part of a workflow item.
+type WorkflowStep struct {
+	Name            string   `json:"name,omitempty"`
+	Command         string   `json:"command"`
+	Arguments       []string `json:"arguments,omitempty"`
+	WorkingDir      string   `json:"workingDir,omitempty"`
+	ContinueOnError bool     `json:"continueOnError,omitempty"`
+}
+
+// Settings holds tray-wide preferences that are not tied to a single item.
+type Settings struct {
+	// Terminal is a command template used to open terminal windows, for
+	// example "alacritty -e {command}". When empty a terminal is detected.
+	Terminal string `json:"terminal,omitempty"`
+	// Locale selects the translation used for labels and descriptions. When
+	// empty it is detected from LC_ALL, LC_MESSAGES, or LANG.
+	Locale string `json:"locale,omitempty"`
+	// Tooltip and Title are templates for the tray icon tooltip and the
+	// title shown next to it where the platform supports one.
+	Tooltip string `json:"tooltip,omitempty"`
+	Title   string `json:"title,omitempty"`
+	// StateIcons maps sync states (syncing, warning, error, offline) to icon
+	// files used instead of the generated variants.
+	StateIcons map[string]string `json:"stateIcons,omitempty"`
+	// Icon and IconDark are image files used as the tray icon on light and
+	// dark desktops. Icons provided by Tactical RMM take precedence.
+	Icon     string `json:"icon,omitempty"`
+	IconDark string `json:"iconDark,omitempty"`
+	// Theme forces the "light" or "dark" icon; "auto" or empty follows the
+	// desktop colour scheme.
+	Theme string `json:"theme,omitempty"`
+	// Badge draws a count or dot over the tray icon when configured.
+	Badge *BadgeSettings `json:"badge,omitempty"`
+}
+
+// BadgeSettings configures the provider polled for the tray icon badge.
+// Either Command or URL must be set; the output is a number, "dot", or an
+// empty string to hide the badge.
+type BadgeSettings struct {
+	Command   string   `json:"command,omitempty"`
+	Arguments []string `json:"arguments,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	// Headers are sent with requests to URL, for example an API token.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Color is the badge fill as "#rrggbb" or a colour name. Defaults to red.
+	Color           string `json:"color,omitempty"`
+	IntervalSeconds int    `json:"intervalSeconds,omitempty"`
 }
 
 // Config represents the persisted configuration file.
 type Config struct {
-	Items []MenuItem `json:"items"`
+	Items    []MenuItem `json:"items"`
+	Settings Settings   `json:"settings,omitzero"`
 }
 
 // Path returns the resolved configuration file path.
@@ -74,13 +168,75 @@ func Path() (string, error) {
 	return filepath.Join(dir, configFileName), nil
 }
 
+// StateDir returns the per-user directory used for runtime state such as
+// captured command output. GOTRAY_STATE_DIR overrides the default location.
+func StateDir() (string, error) {
+	if custom := os.Getenv("GOTRAY_STATE_DIR"); custom != "" {
+		if err := os.MkdirAll(custom, 0o700); err != nil {
+			return "", fmt.Errorf("ensure custom state directory: %w", err)
+		}
+		return custom, nil
+	}
+
+	base, err := userStateDir()
+	if err != nil {
+		return "", fmt.Errorf("determine user state dir: %w", err)
+	}
+
+	dir := filepath.Join(base, configDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("ensure state directory: %w", err)
+	}
+	return dir, nil
+}
+
+func userStateDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		// %LocalAppData% keeps run history out of the roaming profile.
+		return os.UserCacheDir()
+	case "darwin":
+		return os.UserConfigDir()
+	}
+
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state"), nil
+}
+
+// UserPath returns where the configuration of the user with the given home
+// directory is stored by default. GOTRAY_CONFIG_PATH is not consulted since
+// it belongs to the current user.
+func UserPath(home string) string {
+	var base string
+	switch runtime.GOOS {
+	case "windows":
+		base = filepath.Join(home, "AppData", "Roaming")
+	case "darwin":
+		base = filepath.Join(home, "Library", "Application Support")
+	default:
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, configDirName, configFileName)
+}
+
 // Load retrieves the base64-encoded configuration from disk.
 func Load() (*Config, error) {
 	path, err := Path()
 	if err != nil {
 		return nil, err
 	}
+	return LoadFile(path)
+}
 
+// LoadFile reads the configuration stored at path. A missing or empty file
+// yields an empty configuration.
+func LoadFile(path string) (*Config, error) {
 	logging.Debugf("loading configuration from %s", path)
 	raw, err := os.ReadFile(path)
 	if errors.Is(err, os.ErrNotExist) {
@@ -108,6 +264,30 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// Decode parses a configuration payload as produced by the export command,
+// or the plain JSON it wraps.
+func Decode(payload string) (*Config, error) {
+	payload = strings.TrimSpace(payload)
+	if payload == "" {
+		return nil, errors.New("configuration payload is empty")
+	}
+
+	data := []byte(payload)
+	if !strings.HasPrefix(payload, "{") {
+		decoded, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("decode payload: %w", err)
+		}
+		data = decoded
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
 // Save persists the configuration using base64 encoding on disk.
 func Save(cfg *Config) error {
 	raw, err := json.MarshalIndent(cfg, "", "  ")
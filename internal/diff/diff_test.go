@@ -0,0 +1,44 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	want := strings.Join([]string{
+		"--- old",
+		"+++ new",
+		"@@ -1,5 +1,5 @@",
+		" a",
+		"-b",
+		"+B",
+		" c",
+		" d",
+		" e",
+		"@@ -8,3 +8,4 @@",
+		" h",
+		" i",
+		" j",
+		"+k",
+		"",
+	}, "\n")
+	if got := Unified("old", "new", from, to); got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if got := Unified("old", "new", from, from); got != "" {
+		t.Fatalf("expected no diff for equal input, got:\n%s", got)
+	}
+}
+
+func TestUnifiedEmptySides(t *testing.T) {
+	if got := Unified("a", "b", "", "x\n"); got != "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n" {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if got := Unified("a", "b", "x\ny\n", ""); got != "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-x\n-y\n" {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+}
@@ -0,0 +1,169 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/menu"
+)
+
+// ChangeKind classifies a difference between two menus.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Moved   ChangeKind = "moved"
+	Changed ChangeKind = "changed"
+)
+
+// Change is a difference for one menu item, identified by its ID. An item
+// that was both moved and edited produces a Moved and a Changed entry.
+type Change struct {
+	Kind  ChangeKind
+	ID    string
+	Type  config.MenuItemType
+	Label string
+	// Fields names the changed settings of a Changed item.
+	Fields []string
+	// FromParent, ToParent, FromPosition, and ToPosition locate a Moved
+	// item. Positions are 1-based among the siblings in tray order.
+	FromParent   string
+	ToParent     string
+	FromPosition int
+	ToPosition   int
+}
+
+// ignoredFields are bookkeeping fields that do not change how an item
+// behaves; order and parent changes are reported as moves instead.
+var ignoredFields = map[string]bool{
+	"id":         true,
+	"order":      true,
+	"parentId":   true,
+	"createdUtc": true,
+	"updatedUtc": true,
+}
+
+// Items compares two menus by item ID. Items only in to are Added, items
+// only in from are Removed. An item is Moved when its parent changed or its
+// position relative to the siblings present in both menus changed.
+func Items(from, to []config.MenuItem) []Change {
+	fromIndex := indexByID(from)
+	toIndex := indexByID(to)
+	moved := movedIDs(from, to, fromIndex, toIndex)
+
+	var changes []Change
+	for _, item := range from {
+		if _, ok := toIndex[item.ID]; !ok {
+			changes = append(changes, newChange(Removed, item))
+		}
+	}
+	for _, item := range to {
+		before, ok := fromIndex[item.ID]
+		if !ok {
+			changes = append(changes, newChange(Added, item))
+			continue
+		}
+		if moved[item.ID] {
+			change := newChange(Moved, item)
+			change.FromParent, change.ToParent = before.ParentID, item.ParentID
+			change.FromPosition = siblingPosition(from, before)
+			change.ToPosition = siblingPosition(to, item)
+			changes = append(changes, change)
+		}
+		if fields := changedFields(before, item); len(fields) > 0 {
+			change := newChange(Changed, item)
+			change.Fields = fields
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func newChange(kind ChangeKind, item config.MenuItem) Change {
+	return Change{Kind: kind, ID: item.ID, Type: item.Type, Label: item.Label}
+}
+
+func indexByID(items []config.MenuItem) map[string]config.MenuItem {
+	index := make(map[string]config.MenuItem, len(items))
+	for _, item := range items {
+		index[item.ID] = item
+	}
+	return index
+}
+
+// movedIDs reports items whose parent changed, and items that keep their
+// parent but fall outside the longest common ordering of siblings, so one
+// moved item does not mark all of its siblings as moved.
+func movedIDs(from, to []config.MenuItem, fromIndex, toIndex map[string]config.MenuItem) map[string]bool {
+	moved := make(map[string]bool)
+	parents := make(map[string]bool)
+	for id, item := range toIndex {
+		before, ok := fromIndex[id]
+		if !ok {
+			continue
+		}
+		if before.ParentID != item.ParentID {
+			moved[id] = true
+			continue
+		}
+		parents[item.ParentID] = true
+	}
+
+	for parent := range parents {
+		var fromOrder, toOrder []string
+		for _, item := range menu.Children(from, parent) {
+			if after, ok := toIndex[item.ID]; ok && after.ParentID == parent {
+				fromOrder = append(fromOrder, item.ID)
+			}
+		}
+		for _, item := range menu.Children(to, parent) {
+			if before, ok := fromIndex[item.ID]; ok && before.ParentID == parent {
+				toOrder = append(toOrder, item.ID)
+			}
+		}
+		kept := make(map[string]bool)
+		for _, e := range compute(fromOrder, toOrder) {
+			if e.kind == opEqual {
+				kept[e.line] = true
+			}
+		}
+		for _, id := range toOrder {
+			if !kept[id] {
+				moved[id] = true
+			}
+		}
+	}
+	return moved
+}
+
+func siblingPosition(items []config.MenuItem, item config.MenuItem) int {
+	for idx, sibling := range menu.Children(items, item.ParentID) {
+		if sibling.ID == item.ID {
+			return idx + 1
+		}
+	}
+	return 0
+}
+
+// changedFields lists the configuration names of the fields that differ, in
+// declaration order.
+func changedFields(a, b config.MenuItem) []string {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	var fields []string
+	for idx := 0; idx < av.NumField(); idx++ {
+		name, _, _ := strings.Cut(av.Type().Field(idx).Tag.Get("json"), ",")
+		if ignoredFields[name] {
+			continue
+		}
+		x, y := av.Field(idx), bv.Field(idx)
+		if x.IsZero() && y.IsZero() {
+			continue
+		}
+		if !reflect.DeepEqual(x.Interface(), y.Interface()) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
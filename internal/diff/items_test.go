@@ -0,0 +1,42 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func TestItems(t *testing.T) {
+	from := []config.MenuItem{
+		{ID: "10", Type: config.MenuItemText, Label: "A", Order: 40},
+		{ID: "20", Type: config.MenuItemText, Label: "B", Order: 30},
+		{ID: "30", Type: config.MenuItemText, Label: "C", Order: 20},
+		{ID: "40", Type: config.MenuItemMenu, Label: "Tools", Order: 10},
+		{ID: "50", Type: config.MenuItemCommand, Label: "Old", Command: "old", Order: 10, ParentID: "40"},
+		{ID: "60", Type: config.MenuItemCommand, Label: "Run", Command: "run", Order: 20, ParentID: "40"},
+	}
+	to := []config.MenuItem{
+		// C moved in front of A; A and B keep their relative order.
+		{ID: "30", Type: config.MenuItemText, Label: "C", Order: 50},
+		{ID: "10", Type: config.MenuItemText, Label: "A", Order: 40, UpdatedUTC: "2026-01-01T00:00:00Z"},
+		{ID: "20", Type: config.MenuItemText, Label: "B", Order: 30},
+		{ID: "40", Type: config.MenuItemMenu, Label: "Tools", Order: 10},
+		{ID: "60", Type: config.MenuItemCommand, Label: "Run", Command: "run --all", Order: 20},
+		{ID: "70", Type: config.MenuItemURL, Label: "Docs", URL: "https://example.com", Order: 10, ParentID: "40"},
+	}
+
+	want := []Change{
+		{Kind: Removed, ID: "50", Type: config.MenuItemCommand, Label: "Old"},
+		{Kind: Moved, ID: "30", Type: config.MenuItemText, Label: "C", FromPosition: 3, ToPosition: 1},
+		{Kind: Moved, ID: "60", Type: config.MenuItemCommand, Label: "Run", FromParent: "40", FromPosition: 1, ToPosition: 4},
+		{Kind: Changed, ID: "60", Type: config.MenuItemCommand, Label: "Run", Fields: []string{"command"}},
+		{Kind: Added, ID: "70", Type: config.MenuItemURL, Label: "Docs"},
+	}
+	if got := Items(from, to); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected changes:\n got: %+v\nwant: %+v", got, want)
+	}
+	if got := Items(from, from); len(got) != 0 {
+		t.Fatalf("expected no changes for equal menus, got %+v", got)
+	}
+}
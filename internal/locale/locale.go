@@ -0,0 +1,77 @@
+package locale
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// Detect returns the preferred locale: the configured override when set,
+// otherwise the first of LC_ALL, LC_MESSAGES, and LANG that names a real
+// locale, falling back to the operating system preference.
+func Detect(override string) string {
+	if normalized := Normalize(override); normalized != "" {
+		return normalized
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if normalized := Normalize(os.Getenv(name)); normalized != "" {
+			return normalized
+		}
+	}
+	return Normalize(systemLocale())
+}
+
+// Normalize converts POSIX and BCP 47 style names such as "de_DE.UTF-8" or
+// "pt-BR" into lower-case, hyphen separated tags. The "C" and "POSIX"
+// locales carry no language preference and normalise to an empty string.
+func Normalize(raw string) string {
+	value := strings.TrimSpace(raw)
+	if idx := strings.IndexAny(value, ".@"); idx >= 0 {
+		value = value[:idx]
+	}
+	value = strings.ToLower(strings.ReplaceAll(value, "_", "-"))
+	if value == "c" || value == "posix" {
+		return ""
+	}
+	return value
+}
+
+// Validate reports whether raw is usable as a translation key.
+func Validate(raw string) error {
+	tag := Normalize(raw)
+	if tag == "" {
+		return errors.New("locale is empty")
+	}
+	for _, part := range strings.Split(tag, "-") {
+		if part == "" || len(part) > 8 {
+			return errors.New("locale " + raw + " is not a valid language tag")
+		}
+		for _, r := range part {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+				return errors.New("locale " + raw + " is not a valid language tag")
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup picks the translation for locale from values, trying the full tag
+// before its language subtag, and returns fallback when neither is present.
+func Lookup(values map[string]string, locale, fallback string) string {
+	if len(values) == 0 || locale == "" {
+		return fallback
+	}
+	for tag := locale; tag != ""; {
+		for key, value := range values {
+			if Normalize(key) == tag && value != "" {
+				return value
+			}
+		}
+		idx := strings.LastIndex(tag, "-")
+		if idx < 0 {
+			break
+		}
+		tag = tag[:idx]
+	}
+	return fallback
+}
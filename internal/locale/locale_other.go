@@ -0,0 +1,7 @@
+//go:build !windows
+
+package locale
+
+func systemLocale() string {
+	return ""
+}
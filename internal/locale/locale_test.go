@@ -0,0 +1,59 @@
+package locale
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"de_DE.UTF-8":     "de-de",
+		"pt-BR":           "pt-br",
+		"sr_RS@latin":     "sr-rs",
+		"C":               "",
+		"POSIX":           "",
+		"  fr  ":          "fr",
+		"en_US.ISO8859-1": "en-us",
+	}
+	for raw, want := range cases {
+		if got := Normalize(raw); got != want {
+			t.Fatalf("Normalize(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestDetectPrefersOverrideThenEnvironment(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "C")
+	t.Setenv("LANG", "fr_FR.UTF-8")
+
+	if got := Detect("es"); got != "es" {
+		t.Fatalf("expected override, got %q", got)
+	}
+	if got := Detect(""); got != "fr-fr" {
+		t.Fatalf("expected LANG to be used when LC_MESSAGES is C, got %q", got)
+	}
+}
+
+func TestLookupFallsBackToLanguage(t *testing.T) {
+	labels := map[string]string{"de": "Beenden", "pt-BR": "Sair"}
+	if got := Lookup(labels, "de-at", "Quit"); got != "Beenden" {
+		t.Fatalf("expected language fallback, got %q", got)
+	}
+	if got := Lookup(labels, "pt-br", "Quit"); got != "Sair" {
+		t.Fatalf("expected exact match, got %q", got)
+	}
+	if got := Lookup(labels, "ja", "Quit"); got != "Quit" {
+		t.Fatalf("expected default label, got %q", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, ok := range []string{"de", "pt_BR", "zh-Hant-TW"} {
+		if err := Validate(ok); err != nil {
+			t.Fatalf("Validate(%q) returned error: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", "de DE", "en--us", "toolongsubtag"} {
+		if err := Validate(bad); err == nil {
+			t.Fatalf("expected Validate(%q) to fail", bad)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package locale
+
+import "golang.org/x/sys/windows"
+
+func systemLocale() string {
+	languages, err := windows.GetUserPreferredUILanguages(windows.MUI_LANGUAGE_NAME)
+	if err != nil || len(languages) == 0 {
+		return ""
+	}
+	return languages[0]
+}
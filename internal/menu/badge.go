@@ -0,0 +1,164 @@
+package menu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+)
+
+const (
+	defaultBadgeInterval = 60 * time.Second
+	minBadgeInterval     = 5 * time.Second
+	badgeTimeout         = 10 * time.Second
+	maxBadgeOutput       = 64 << 10
+)
+
+// badgeConfigured reports whether settings define a badge provider.
+func badgeConfigured(settings config.Settings) bool {
+	badge := settings.Badge
+	return badge != nil && (strings.TrimSpace(badge.Command) != "" || strings.TrimSpace(badge.URL) != "")
+}
+
+func badgeInterval(settings config.BadgeSettings) time.Duration {
+	if settings.IntervalSeconds <= 0 {
+		return defaultBadgeInterval
+	}
+	return max(minBadgeInterval, time.Duration(settings.IntervalSeconds)*time.Second)
+}
+
+// badgeKey identifies a badge configuration so a changed provider is polled
+// straight away instead of after the previous interval.
+func badgeKey(settings config.Settings) string {
+	if !badgeConfigured(settings) {
+		return ""
+	}
+	payload, err := json.Marshal(settings.Badge)
+	if err != nil {
+		return ""
+	}
+	return string(payload)
+}
+
+// nextBadgeRun reports how long to wait before the badge provider is due, or
+// zero when no badge is configured or shown.
+func (r *Runner) nextBadgeRun(now time.Time) time.Duration {
+	settings := r.latestSettings()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !badgeConfigured(settings) {
+		if r.badge.visible() {
+			return time.Millisecond
+		}
+		return 0
+	}
+	if r.badgeKey != badgeKey(settings) {
+		return time.Millisecond
+	}
+	wait := badgeInterval(*settings.Badge) - now.Sub(r.badgeRun)
+	if wait < time.Millisecond {
+		wait = time.Millisecond
+	}
+	return wait
+}
+
+// refreshBadge polls the badge provider and pushes the result to the tray.
+// A failing provider keeps the previous count.
+func (r *Runner) refreshBadge(ctx context.Context) {
+	settings := r.latestSettings()
+
+	r.mu.RLock()
+	previous := r.badge
+	r.mu.RUnlock()
+
+	var badge Badge
+	if badgeConfigured(settings) {
+		fill, err := ParseBadgeColor(settings.Badge.Color)
+		if err != nil {
+			log.Printf("badge: %v", err)
+			fill = defaultBadgeColor
+		}
+		output, err := readBadgeOutput(ctx, *settings.Badge)
+		if err == nil {
+			badge, err = parseBadgeOutput(output)
+		}
+		if err != nil {
+			log.Printf("badge provider failed: %v", err)
+			badge = previous
+		}
+		badge.Color = fill
+	}
+
+	r.mu.Lock()
+	r.badgeRun = time.Now()
+	r.badgeKey = badgeKey(settings)
+	r.badge = badge
+	changed := badge != previous
+	r.mu.Unlock()
+
+	if changed && r.tray != nil {
+		logging.Debugf("tray badge updated: count=%d dot=%t", badge.Count, badge.Dot)
+		r.tray.SetBadge(badge)
+	}
+}
+
+func readBadgeOutput(ctx context.Context, settings config.BadgeSettings) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, badgeTimeout)
+	defer cancel()
+
+	if url := strings.TrimSpace(settings.URL); url != "" {
+		return fetchBadge(ctx, url, settings.Headers)
+	}
+
+	cmd := exec.CommandContext(ctx, settings.Command, settings.Arguments...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &stdout, limit: maxBadgeOutput}
+	cmd.Stderr = &limitedBuffer{buf: &stderr, limit: 4 << 10}
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("badge command timed out after %s", badgeTimeout)
+		}
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return nil, fmt.Errorf("%w: %s", err, detail)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+func fetchBadge(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("badge request returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBadgeOutput+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBadgeOutput {
+		return nil, errors.New("badge response exceeds 64 KiB")
+	}
+	return body, nil
+}
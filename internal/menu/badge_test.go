@@ -0,0 +1,151 @@
+package menu
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func TestParseBadgeOutput(t *testing.T) {
+	cases := map[string]Badge{
+		"":                 {},
+		" 0\n":             {},
+		"12\n":             {Count: 12},
+		"dot":              {Dot: true},
+		`{"count": 3}`:     {Count: 3},
+		`{"dot": true}`:    {Dot: true},
+		`{"count": -1}`:    {},
+		"false":            {},
+		"  TRUE  ":         {Dot: true},
+		`{"count": 120}  `: {Count: 120},
+	}
+	for input, want := range cases {
+		got, err := parseBadgeOutput([]byte(input))
+		if err != nil || got != want {
+			t.Fatalf("parseBadgeOutput(%q) = %+v, %v; want %+v", input, got, err, want)
+		}
+	}
+	if _, err := parseBadgeOutput([]byte("three")); err == nil {
+		t.Fatalf("expected non-numeric output to be rejected")
+	}
+	if got := badgeText(250); got != "99+" {
+		t.Fatalf("unexpected badge text %q", got)
+	}
+}
+
+func TestParseBadgeColor(t *testing.T) {
+	if got, err := ParseBadgeColor("#0a0"); err != nil || got != (color.NRGBA{G: 0xaa, A: 0xff}) {
+		t.Fatalf("unexpected colour %+v, %v", got, err)
+	}
+	if got, err := ParseBadgeColor("Blue"); err != nil || got != badgeColorNames["blue"] {
+		t.Fatalf("unexpected colour %+v, %v", got, err)
+	}
+	if _, err := ParseBadgeColor("#12345"); err == nil {
+		t.Fatalf("expected an invalid colour to be rejected")
+	}
+}
+
+func TestDrawBadgeLeavesBottomOfIconUntouched(t *testing.T) {
+	base := color.NRGBA{R: 0x20, G: 0xa0, B: 0x40, A: 0xff}
+	icon := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for idx := 0; idx < len(icon.Pix); idx += 4 {
+		icon.Pix[idx], icon.Pix[idx+1], icon.Pix[idx+2], icon.Pix[idx+3] = base.R, base.G, base.B, base.A
+	}
+
+	img := drawBadge(icon, Badge{Count: 7, Color: defaultBadgeColor})
+	inked := false
+	for y := 0; y < 16; y++ {
+		for x := 16; x < 32; x++ {
+			if img.NRGBAAt(x, y) == (color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}) {
+				inked = true
+			}
+		}
+	}
+	if !inked || img.NRGBAAt(26, 8) == base {
+		t.Fatalf("expected the badge to be drawn in the top right corner")
+	}
+	if img.NRGBAAt(4, 28) != base || img.NRGBAAt(28, 28) != base {
+		t.Fatalf("expected the bottom of the icon to be unchanged")
+	}
+}
+
+func TestWithBadgeUsesNormalizedIcon(t *testing.T) {
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><rect width="24" height="24" fill="#20a040"/></svg>`)
+	data, err := withBadge(svg, Badge{Dot: true, Color: defaultBadgeColor})
+	if err != nil {
+		t.Fatalf("expected a badge on the SVG icon: %v", err)
+	}
+	img, err := decodeIcon(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := img.Bounds().Dx()
+	if got := color.NRGBAModel.Convert(img.At(size-size/6, size/6)).(color.NRGBA); got != defaultBadgeColor {
+		t.Fatalf("expected the badge in the top right corner, got %+v", got)
+	}
+
+	wide, err := encodePNG(image.NewNRGBA(image.Rect(0, 0, maxIconDimension+1, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = withBadge(wide, Badge{Count: 3})
+	if err != nil {
+		t.Fatalf("withBadge: %v", err)
+	}
+	if img, err := decodeIcon(data); err != nil || img.Bounds().Dx() > maxIconDimension {
+		t.Fatalf("expected the oversized icon to be replaced before drawing, got %v", err)
+	}
+}
+
+func TestRefreshBadgeKeepsCountWhenProviderFails(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"count": 4}`))
+	}))
+	defer server.Close()
+
+	tray := &statusRecorder{}
+	r := &Runner{tray: tray}
+	r.lastSettings = config.Settings{Badge: &config.BadgeSettings{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Token secret"},
+		Color:   "blue",
+	}}
+
+	if wait := r.nextBadgeRun(time.Now()); wait != time.Millisecond {
+		t.Fatalf("expected a new badge provider to be due immediately, got %s", wait)
+	}
+	r.refreshBadge(context.Background())
+	if tray.badge.Count != 4 || tray.badge.Color != badgeColorNames["blue"] {
+		t.Fatalf("unexpected badge %+v", tray.badge)
+	}
+	if wait := r.nextBadgeRun(time.Now()); wait < 50*time.Second {
+		t.Fatalf("expected the next poll after the default interval, got %s", wait)
+	}
+
+	status = http.StatusInternalServerError
+	r.refreshBadge(context.Background())
+	if tray.badge.Count != 4 {
+		t.Fatalf("expected the previous count after a failure, got %+v", tray.badge)
+	}
+
+	r.lastSettings = config.Settings{}
+	if wait := r.nextBadgeRun(time.Now()); wait != time.Millisecond {
+		t.Fatalf("expected a removed badge to be cleared immediately, got %s", wait)
+	}
+	r.refreshBadge(context.Background())
+	if tray.badge.visible() {
+		t.Fatalf("expected the badge to be cleared, got %+v", tray.badge)
+	}
+}
@@ -16,36 +16,89 @@ func DefaultItems() []config.MenuItem {
 			Type:        config.MenuItemText,
 			Label:       "GoTray",
 			Description: "GoTray is running",
-			CreatedUTC:  now,
-			UpdatedUTC:  now,
+			Descriptions: map[string]string{
+				"de": "GoTray läuft",
+				"es": "GoTray se está ejecutando",
+				"fr": "GoTray est en cours d'exécution",
+			},
+			CreatedUTC: now,
+			UpdatedUTC: now,
 		},
 		{
 			ID:          "20",
 			Order:       20,
 			Type:        config.MenuItemRefresh,
 			Label:       "Refresh",
+			Labels:      map[string]string{"de": "Aktualisieren", "es": "Actualizar", "fr": "Actualiser"},
 			Description: "Reload the latest configuration",
-			CreatedUTC:  now,
-			UpdatedUTC:  now,
+			Descriptions: map[string]string{
+				"de": "Neueste Konfiguration laden",
+				"es": "Volver a cargar la configuración más reciente",
+				"fr": "Recharger la dernière configuration",
+			},
+			CreatedUTC: now,
+			UpdatedUTC: now,
 		},
 		{
 			ID:          "30",
 			Order:       30,
 			Type:        config.MenuItemURL,
 			Label:       "Visit Project",
+			Labels:      map[string]string{"de": "Projekt besuchen", "es": "Visitar el proyecto", "fr": "Visiter le projet"},
 			URL:         "https://example.com",
 			Description: "Open the GoTray project page",
-			CreatedUTC:  now,
-			UpdatedUTC:  now,
+			Descriptions: map[string]string{
+				"de": "GoTray-Projektseite öffnen",
+				"es": "Abrir la página del proyecto GoTray",
+				"fr": "Ouvrir la page du projet GoTray",
+			},
+			CreatedUTC: now,
+			UpdatedUTC: now,
 		},
 		{
 			ID:          "40",
 			Order:       40,
+			Type:        config.MenuItemResults,
+			Label:       "Last results",
+			Labels:      map[string]string{"de": "Letzte Ergebnisse", "es": "Últimos resultados", "fr": "Derniers résultats"},
+			Description: "Show the output of recent command runs",
+			Descriptions: map[string]string{
+				"de": "Ausgabe der letzten Befehlsausführungen anzeigen",
+				"es": "Mostrar la salida de los últimos comandos ejecutados",
+				"fr": "Afficher la sortie des dernières commandes exécutées",
+			},
+			CreatedUTC: now,
+			UpdatedUTC: now,
+		},
+		{
+			ID:          "50",
+			Order:       50,
+			Type:        config.MenuItemTasks,
+			Label:       "Running tasks",
+			Labels:      map[string]string{"de": "Laufende Aufgaben", "es": "Tareas en ejecución", "fr": "Tâches en cours"},
+			Description: "Stop commands that are still running",
+			Descriptions: map[string]string{
+				"de": "Noch laufende Befehle beenden",
+				"es": "Detener los comandos que siguen en ejecución",
+				"fr": "Arrêter les commandes encore en cours",
+			},
+			CreatedUTC: now,
+			UpdatedUTC: now,
+		},
+		{
+			ID:          "60",
+			Order:       60,
 			Type:        config.MenuItemQuit,
 			Label:       "Quit GoTray",
+			Labels:      map[string]string{"de": "GoTray beenden", "es": "Salir de GoTray", "fr": "Quitter GoTray"},
 			Description: "Exit the GoTray application",
-			CreatedUTC:  now,
-			UpdatedUTC:  now,
+			Descriptions: map[string]string{
+				"de": "Die GoTray-Anwendung beenden",
+				"es": "Cerrar la aplicación GoTray",
+				"fr": "Fermer l'application GoTray",
+			},
+			CreatedUTC: now,
+			UpdatedUTC: now,
 		},
 	}
 }
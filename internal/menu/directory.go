@@ -0,0 +1,177 @@
+package menu
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+)
+
+// scriptMetadata is read from an optional sidecar file next to a script,
+// named after the script with its extension replaced by ".json".
+type scriptMetadata struct {
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// expandDirectories appends a command item for every executable file found in
+// the directories referenced by menu items.
+func expandDirectories(items []config.MenuItem) []config.MenuItem {
+	out := make([]config.MenuItem, len(items))
+	copy(out, items)
+
+	for _, item := range items {
+		if item.Type != config.MenuItemMenu || strings.TrimSpace(item.Directory) == "" {
+			continue
+		}
+		children, err := scanScriptDirectory(item)
+		if err != nil {
+			log.Printf("menu %s: %v", item.ID, err)
+			out = append(out, unavailableItem(item, err))
+			continue
+		}
+		out = append(out, children...)
+	}
+	return out
+}
+
+// hasDirectories reports whether any menu item is populated from a directory.
+func hasDirectories(items []config.MenuItem) bool {
+	for _, item := range items {
+		if item.Type == config.MenuItemMenu && strings.TrimSpace(item.Directory) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func scanScriptDirectory(item config.MenuItem) ([]config.MenuItem, error) {
+	dir := strings.TrimSpace(item.Directory)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read script directory: %w", err)
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	children := make([]config.MenuItem, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || strings.EqualFold(filepath.Ext(name), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || !isExecutableScript(name, info.Mode()) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		command, args := scriptCommand(path)
+		child := config.MenuItem{
+			ID:         item.ID + generatedIDSeparator + name,
+			Type:       config.MenuItemCommand,
+			Label:      strings.TrimSuffix(name, filepath.Ext(name)),
+			Command:    command,
+			Arguments:  args,
+			WorkingDir: dir,
+			ParentID:   item.ID,
+		}
+
+		meta, err := readScriptMetadata(dir, name, names)
+		if err != nil {
+			logging.Debugf("ignoring metadata for script %s: %v", path, err)
+		}
+		if meta.Label != "" {
+			child.Label = meta.Label
+		}
+		child.Description = meta.Description
+		if meta.Icon != "" {
+			child.Icon = meta.Icon
+			if !filepath.IsAbs(child.Icon) {
+				child.Icon = filepath.Join(dir, child.Icon)
+			}
+		}
+		children = append(children, child)
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		return strings.ToLower(children[i].Label) < strings.ToLower(children[j].Label)
+	})
+	orderAsListed(children)
+	return children, nil
+}
+
+func readScriptMetadata(dir, name string, names map[string]bool) (scriptMetadata, error) {
+	var meta scriptMetadata
+	candidates := []string{strings.TrimSuffix(name, filepath.Ext(name)) + ".json", name + ".json"}
+	for _, candidate := range candidates {
+		if !names[candidate] {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, candidate))
+		if err != nil {
+			return meta, err
+		}
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return meta, fmt.Errorf("parse %s: %w", candidate, err)
+		}
+		return meta, nil
+	}
+	return meta, nil
+}
+
+// directorySignature summarises the script directories so the runner can
+// detect added, removed, or modified scripts and sidecar files.
+func directorySignature(items []config.MenuItem) string {
+	hash := sha256.New()
+	for _, item := range items {
+		if item.Type != config.MenuItemMenu || strings.TrimSpace(item.Directory) == "" {
+			continue
+		}
+		fmt.Fprintf(hash, "%s\x00", item.Directory)
+		entries, err := os.ReadDir(strings.TrimSpace(item.Directory))
+		if err != nil {
+			fmt.Fprintf(hash, "error:%v\x00", err)
+			continue
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(hash, "%s|%d|%d|%o\x00", entry.Name(), info.Size(), info.ModTime().UnixNano(), info.Mode())
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// orderAsListed assigns descending order values so the tray, which shows
+// higher orders first, presents the items in slice order.
+func orderAsListed(items []config.MenuItem) {
+	for idx := range items {
+		items[idx].Order = (len(items) - idx) * 10
+	}
+}
+
+func unavailableItem(item config.MenuItem, err error) config.MenuItem {
+	return config.MenuItem{
+		ID:          item.ID + generatedIDSeparator + "error",
+		Order:       10,
+		Type:        config.MenuItemText,
+		Label:       "Unavailable",
+		Description: err.Error(),
+		ParentID:    item.ID,
+	}
+}
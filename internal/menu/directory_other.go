@@ -0,0 +1,14 @@
+//go:build !windows
+
+package menu
+
+import "os"
+
+// isExecutableScript accepts files with any execute permission bit set.
+func isExecutableScript(_ string, mode os.FileMode) bool {
+	return mode&0o111 != 0
+}
+
+func scriptCommand(path string) (string, []string) {
+	return path, nil
+}
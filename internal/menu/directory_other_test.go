@@ -0,0 +1,61 @@
+//go:build !windows
+
+package menu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func TestScanScriptDirectory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string, mode os.FileMode) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("flush-dns.sh", "#!/bin/sh\n", 0o755)
+	write("flush-dns.json", `{"label": "Flush DNS cache", "description": "Clears the resolver cache", "icon": "dns.png"}`, 0o644)
+	write("backup", "#!/bin/sh\n", 0o755)
+	write("README.txt", "not a script", 0o644)
+	write(".hidden", "#!/bin/sh\n", 0o755)
+
+	tools := config.MenuItem{ID: "10.10", Type: config.MenuItemMenu, Label: "Tools", ParentID: "10", Directory: dir}
+	items := []config.MenuItem{tools}
+	before := directorySignature(items)
+
+	expanded := expandDirectories(items)
+	if len(expanded) != 3 {
+		t.Fatalf("expected 2 scripts, got %+v", expanded[1:])
+	}
+	backup, flush := expanded[1], expanded[2]
+	if backup.Label != "backup" || backup.Command != filepath.Join(dir, "backup") || backup.ParentID != "10.10" {
+		t.Fatalf("unexpected script item %+v", backup)
+	}
+	if flush.ID != "10.10:flush-dns.sh" || flush.Label != "Flush DNS cache" || flush.Description != "Clears the resolver cache" {
+		t.Fatalf("sidecar metadata not applied: %+v", flush)
+	}
+	if flush.Icon != filepath.Join(dir, "dns.png") {
+		t.Fatalf("expected icon relative to the directory, got %s", flush.Icon)
+	}
+	if backup.Order <= flush.Order {
+		t.Fatalf("expected scripts to be ordered by label")
+	}
+
+	write("restart-vpn.sh", "#!/bin/sh\n", 0o755)
+	if directorySignature(items) == before {
+		t.Fatalf("expected the signature to change when a script is added")
+	}
+}
+
+func TestExpandDirectoriesReportsMissingDirectory(t *testing.T) {
+	items := []config.MenuItem{{ID: "10.10", Type: config.MenuItemMenu, Label: "Tools", ParentID: "10", Directory: filepath.Join(t.TempDir(), "missing")}}
+	expanded := expandDirectories(items)
+	if len(expanded) != 2 || expanded[1].ID != "10.10:error" {
+		t.Fatalf("expected an unavailable placeholder, got %+v", expanded)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package menu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isExecutableScript accepts the file types Windows can launch directly plus
+// PowerShell scripts.
+func isExecutableScript(name string, _ os.FileMode) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".exe", ".com", ".bat", ".cmd", ".ps1":
+		return true
+	default:
+		return false
+	}
+}
+
+func scriptCommand(path string) (string, []string) {
+	if strings.EqualFold(filepath.Ext(path), ".ps1") {
+		return "powershell.exe", []string{"-NoProfile", "-ExecutionPolicy", "Bypass", "-File", path}
+	}
+	return path, nil
+}
@@ -0,0 +1,320 @@
+package menu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+)
+
+const (
+	defaultGeneratorTimeout = 10 * time.Second
+	maxGeneratorOutput      = 1 << 20
+
+	// generatedIDSeparator joins a generator's ID with the IDs its output
+	// declares so generated children never collide with configured items.
+	generatedIDSeparator = ":"
+)
+
+// generatorState remembers the last successful expansion of a generator so
+// a failing command does not wipe out a previously populated submenu.
+// running is set while the generator is evaluated in the background.
+type generatorState struct {
+	children []config.MenuItem
+	lastRun  time.Time
+	running  bool
+}
+
+// generatorResult carries the outcome of a background generator run back to
+// the runner loop.
+type generatorResult struct {
+	item     config.MenuItem
+	children []config.MenuItem
+	err      error
+}
+
+// expandGenerators appends the children produced by every generator item.
+// When due is non-nil only the generators it selects are re-evaluated; the
+// others reuse their most recent output. Evaluation happens in the
+// background so slow commands do not hold up the runner loop: the menu is
+// built from the last output and rebuilt once applyGeneratorResult reports
+// a change.
+func (r *Runner) expandGenerators(ctx context.Context, items []config.MenuItem, due func(config.MenuItem) bool) []config.MenuItem {
+	out := make([]config.MenuItem, len(items))
+	copy(out, items)
+
+	r.genMu.Lock()
+	defer r.genMu.Unlock()
+	if r.generators == nil {
+		r.generators = make(map[string]*generatorState)
+	}
+	if r.generatorResults == nil {
+		r.generatorResults = make(chan generatorResult)
+	}
+
+	active := make(map[string]bool)
+	for _, item := range items {
+		if item.Type != config.MenuItemGenerator {
+			continue
+		}
+		active[item.ID] = true
+
+		state := r.generators[item.ID]
+		if state == nil {
+			state = &generatorState{}
+			r.generators[item.ID] = state
+		}
+		if !state.running && (due == nil || due(item) || state.lastRun.IsZero()) {
+			state.lastRun = time.Now()
+			state.running = true
+			go r.runGenerator(ctx, item)
+		}
+		if state.children == nil {
+			out = append(out, pendingItem(item))
+			continue
+		}
+		out = append(out, state.children...)
+	}
+
+	for id := range r.generators {
+		if !active[id] {
+			delete(r.generators, id)
+		}
+	}
+	return out
+}
+
+func (r *Runner) runGenerator(ctx context.Context, item config.MenuItem) {
+	children, err := generateChildren(ctx, item)
+	select {
+	case r.generatorResults <- generatorResult{item: item, children: children, err: err}:
+	case <-ctx.Done():
+	}
+}
+
+// applyGeneratorResult stores the output of a background run and reports
+// whether the menu needs to be rebuilt.
+func (r *Runner) applyGeneratorResult(result generatorResult) bool {
+	r.genMu.Lock()
+	defer r.genMu.Unlock()
+
+	state := r.generators[result.item.ID]
+	if state == nil {
+		// The generator was removed while it ran.
+		return false
+	}
+	state.running = false
+	if result.err != nil {
+		log.Printf("generator %s failed: %v", result.item.ID, result.err)
+		if state.children != nil {
+			return false
+		}
+		state.children = []config.MenuItem{unavailableItem(result.item, result.err)}
+		return true
+	}
+	changed := state.children == nil || hashItems(result.children) != hashItems(state.children)
+	state.children = result.children
+	return changed
+}
+
+// pendingItem stands in for the entries of a generator whose first run has
+// not finished yet.
+func pendingItem(item config.MenuItem) config.MenuItem {
+	return config.MenuItem{
+		ID:       item.ID + generatedIDSeparator + "pending",
+		Order:    10,
+		Type:     config.MenuItemText,
+		Label:    "Loading…",
+		ParentID: item.ID,
+	}
+}
+
+// nextGeneratorRun reports how long to wait before the earliest scheduled
+// generator is due, or zero when no generator has an interval.
+func (r *Runner) nextGeneratorRun(items []config.MenuItem, now time.Time) time.Duration {
+	r.genMu.Lock()
+	defer r.genMu.Unlock()
+
+	var next time.Duration
+	for _, item := range items {
+		if item.Type != config.MenuItemGenerator || item.IntervalSeconds <= 0 {
+			continue
+		}
+		wait := time.Duration(item.IntervalSeconds) * time.Second
+		if state := r.generators[item.ID]; state != nil {
+			if state.running {
+				// The result wakes the loop when it arrives.
+				continue
+			}
+			wait -= now.Sub(state.lastRun)
+		}
+		if wait < time.Millisecond {
+			wait = time.Millisecond
+		}
+		if next == 0 || wait < next {
+			next = wait
+		}
+	}
+	return next
+}
+
+// generatorDue selects generators whose interval has elapsed. The returned
+// function must only be called by expandGenerators, which holds genMu.
+func (r *Runner) generatorDue(now time.Time) func(config.MenuItem) bool {
+	return func(item config.MenuItem) bool {
+		if item.IntervalSeconds <= 0 {
+			return false
+		}
+		state := r.generators[item.ID]
+		return state == nil || now.Sub(state.lastRun) >= time.Duration(item.IntervalSeconds)*time.Second
+	}
+}
+
+// generateChildren runs a generator's command or reads its source file and
+// converts the JSON output into validated, namespaced menu items.
+func generateChildren(ctx context.Context, item config.MenuItem) ([]config.MenuItem, error) {
+	raw, err := readGeneratorOutput(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	var declared []config.MenuItem
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &declared); err != nil {
+		return nil, fmt.Errorf("decode generator output: %w", err)
+	}
+	return namespaceChildren(item, declared), nil
+}
+
+func readGeneratorOutput(ctx context.Context, item config.MenuItem) ([]byte, error) {
+	if source := strings.TrimSpace(item.Source); source != "" {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("read generator source: %w", err)
+		}
+		return data, nil
+	}
+
+	timeout := defaultGeneratorTimeout
+	if item.TimeoutSeconds > 0 {
+		timeout = time.Duration(item.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, item.Command, item.Arguments...)
+	if item.WorkingDir != "" {
+		cmd.Dir = item.WorkingDir
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &stdout, limit: maxGeneratorOutput}
+	cmd.Stderr = &limitedBuffer{buf: &stderr, limit: 4 << 10}
+
+	logging.Debugf("running generator %s: %s %v", item.ID, item.Command, item.Arguments)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("generator timed out after %s", timeout)
+		}
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return nil, fmt.Errorf("%w: %s", err, detail)
+		}
+		return nil, err
+	}
+	if stdout.Len() >= maxGeneratorOutput {
+		return nil, errors.New("generator output exceeds 1 MiB")
+	}
+	return stdout.Bytes(), nil
+}
+
+// namespaceChildren prefixes declared IDs with the generator's ID, attaches
+// top-level entries to the generator, and drops entries that fail validation.
+func namespaceChildren(parent config.MenuItem, declared []config.MenuItem) []config.MenuItem {
+	ids := make(map[string]string, len(declared))
+	for idx := range declared {
+		id := strings.TrimSpace(declared[idx].ID)
+		if id == "" {
+			id = strconv.Itoa(idx + 1)
+		}
+		declared[idx].ID = id
+		if _, exists := ids[id]; !exists {
+			ids[id] = parent.ID + generatedIDSeparator + id
+		}
+	}
+
+	children := make([]config.MenuItem, 0, len(declared))
+	seen := make(map[string]bool, len(declared))
+	for idx, child := range declared {
+		if err := prepareChild(&child, parent.ID, ids); err != nil {
+			log.Printf("generator %s: skipping item %d: %v", parent.ID, idx+1, err)
+			continue
+		}
+		if seen[child.ID] {
+			log.Printf("generator %s: skipping item %d: duplicate id %s", parent.ID, idx+1, declared[idx].ID)
+			continue
+		}
+		seen[child.ID] = true
+		children = append(children, child)
+	}
+	if declaresOrder(children) {
+		EnsureSequentialOrder(&children)
+	} else {
+		orderAsListed(children)
+	}
+	return children
+}
+
+func prepareChild(child *config.MenuItem, parentID string, ids map[string]string) error {
+	if child.Type == config.MenuItemGenerator {
+		return errors.New("generated items cannot be generators")
+	}
+	child.ID = ids[child.ID]
+	if child.ParentID == "" {
+		child.ParentID = parentID
+	} else {
+		mapped, ok := ids[child.ParentID]
+		if !ok {
+			return fmt.Errorf("parent id %s not found in generator output", child.ParentID)
+		}
+		if mapped == child.ID {
+			return errors.New("item cannot reference itself as parent")
+		}
+		child.ParentID = mapped
+	}
+	return ValidateItem(*child)
+}
+
+func declaresOrder(items []config.MenuItem) bool {
+	for _, item := range items {
+		if item.Order != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedBuffer stops accepting data once limit bytes have been written while
+// still reporting success so the child process is not killed by EPIPE.
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := l.limit - l.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			l.buf.Write(p[:remaining])
+		} else {
+			l.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package menu
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func TestSlowGeneratorsDoNotBlockExpansion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := &Runner{}
+	items := []config.MenuItem{
+		{ID: "10", Type: config.MenuItemGenerator, Label: "Slow", Command: "sleep", Arguments: []string{"5"}, IntervalSeconds: 1},
+		{ID: "20", Type: config.MenuItemGenerator, Label: "Slower", Command: "sleep", Arguments: []string{"5"}, IntervalSeconds: 1},
+	}
+
+	started := time.Now()
+	expanded := r.expandGenerators(ctx, items, nil)
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("expected expansion to return at once, took %s", elapsed)
+	}
+	if len(expanded) != 4 || expanded[2].ID != "10:pending" || expanded[3].ID != "20:pending" {
+		t.Fatalf("expected placeholders while the generators run, got %+v", expanded)
+	}
+	if wait := r.nextGeneratorRun(items, time.Now()); wait != 0 {
+		t.Fatalf("expected running generators not to be scheduled again, got %s", wait)
+	}
+
+	r.expandGenerators(ctx, items, nil)
+	r.genMu.Lock()
+	running := r.generators["10"].running && r.generators["20"].running
+	r.genMu.Unlock()
+	if !running {
+		t.Fatalf("expected both generators to still be running")
+	}
+}
@@ -0,0 +1,90 @@
+package menu
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func TestNamespaceChildren(t *testing.T) {
+	parent := config.MenuItem{ID: "30", Type: config.MenuItemGenerator, Label: "Printers"}
+	children := namespaceChildren(parent, []config.MenuItem{
+		{ID: "office", Type: config.MenuItemMenu, Label: "Office"},
+		{Type: config.MenuItemURL, Label: "Queue", URL: "http://print/queue", ParentID: "office"},
+		{Type: config.MenuItemText},
+		{Type: config.MenuItemGenerator, Label: "Nested", Command: "true"},
+		{Type: config.MenuItemText, Label: "Orphan", ParentID: "missing"},
+	})
+
+	if len(children) != 2 {
+		t.Fatalf("expected 2 valid children, got %d: %+v", len(children), children)
+	}
+	if children[0].ID != "30:office" || children[0].ParentID != "30" {
+		t.Fatalf("unexpected submenu %+v", children[0])
+	}
+	if children[1].ID != "30:2" || children[1].ParentID != "30:office" {
+		t.Fatalf("unexpected nested child %+v", children[1])
+	}
+}
+
+func TestExpandGeneratorsKeepsLastGoodOutput(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "shares.json")
+	if err := os.WriteFile(source, []byte(`[{"type": "text", "label": "Projects"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Runner{}
+	items := []config.MenuItem{{ID: "10", Type: config.MenuItemGenerator, Label: "Shares", Source: source}}
+	never := func(config.MenuItem) bool { return false }
+
+	expanded := r.expandGenerators(context.Background(), items, nil)
+	if len(expanded) != 2 || expanded[1].ID != "10:pending" {
+		t.Fatalf("expected a placeholder until the first run finishes, got %+v", expanded)
+	}
+	if !r.applyGeneratorResult(receiveGeneratorResult(t, r)) {
+		t.Fatalf("expected the first output to rebuild the menu")
+	}
+	expanded = r.expandGenerators(context.Background(), items, never)
+	if len(expanded) != 2 || expanded[1].Label != "Projects" || expanded[1].ParentID != "10" {
+		t.Fatalf("unexpected expansion %+v", expanded)
+	}
+
+	if err := os.WriteFile(source, []byte(`not json`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r.expandGenerators(context.Background(), items, nil)
+	if r.applyGeneratorResult(receiveGeneratorResult(t, r)) {
+		t.Fatalf("expected a failure not to rebuild the menu")
+	}
+	expanded = r.expandGenerators(context.Background(), items, never)
+	if len(expanded) != 2 || expanded[1].Label != "Projects" {
+		t.Fatalf("expected previous children after a failure, got %+v", expanded)
+	}
+
+	items[0].Source = filepath.Join(t.TempDir(), "missing.json")
+	items[0].ID = "20"
+	r.expandGenerators(context.Background(), items, nil)
+	r.applyGeneratorResult(receiveGeneratorResult(t, r))
+	expanded = r.expandGenerators(context.Background(), items, never)
+	if len(expanded) != 2 || expanded[1].ID != "20:error" {
+		t.Fatalf("expected an error placeholder, got %+v", expanded)
+	}
+	if _, ok := r.generators["10"]; ok {
+		t.Fatalf("expected state for removed generators to be pruned")
+	}
+}
+
+func receiveGeneratorResult(t *testing.T, r *Runner) generatorResult {
+	t.Helper()
+	select {
+	case result := <-r.generatorResults:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatalf("generator did not finish")
+		return generatorResult{}
+	}
+}
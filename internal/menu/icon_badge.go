@@ -0,0 +1,184 @@
+package menu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Badge is drawn over the top right corner of the tray icon. A positive
+// Count is shown as a number; otherwise Dot shows a plain dot.
+type Badge struct {
+	Count int
+	Dot   bool
+	Color color.NRGBA
+}
+
+func (b Badge) visible() bool {
+	return b.Count > 0 || b.Dot
+}
+
+var defaultBadgeColor = color.NRGBA{R: 0xe0, G: 0x31, B: 0x31, A: 0xff}
+
+var badgeColorNames = map[string]color.NRGBA{
+	"red":    defaultBadgeColor,
+	"orange": {R: 0xf5, G: 0x7c, B: 0x00, A: 0xff},
+	"yellow": {R: 0xf2, G: 0xc1, B: 0x00, A: 0xff},
+	"green":  {R: 0x2f, G: 0x9e, B: 0x44, A: 0xff},
+	"blue":   {R: 0x2f, G: 0x80, B: 0xed, A: 0xff},
+	"purple": {R: 0x8e, G: 0x44, B: 0xad, A: 0xff},
+	"grey":   {R: 0x86, G: 0x8e, B: 0x96, A: 0xff},
+	"gray":   {R: 0x86, G: 0x8e, B: 0x96, A: 0xff},
+	"black":  {R: 0x21, G: 0x25, B: 0x29, A: 0xff},
+}
+
+// ParseBadgeColor accepts "#rgb", "#rrggbb", or a colour name such as "red"
+// or "blue". An empty value selects the default red.
+func ParseBadgeColor(value string) (color.NRGBA, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return defaultBadgeColor, nil
+	}
+	if named, ok := badgeColorNames[value]; ok {
+		return named, nil
+	}
+	if hex, ok := parseHexColor(value); ok {
+		return hex, nil
+	}
+	return color.NRGBA{}, fmt.Errorf("invalid badge colour %q; use #rrggbb or a name such as red or blue", value)
+}
+
+// parseBadgeOutput interprets provider output: a number, "dot" or "true" for
+// a plain dot, an empty string, "0", or "false" to hide the badge, or a JSON
+// object such as {"count": 3} or {"dot": true}.
+func parseBadgeOutput(output []byte) (Badge, error) {
+	text := strings.TrimSpace(string(output))
+	if strings.HasPrefix(text, "{") {
+		var payload struct {
+			Count int  `json:"count"`
+			Dot   bool `json:"dot"`
+		}
+		if err := json.Unmarshal([]byte(text), &payload); err != nil {
+			return Badge{}, fmt.Errorf("decode badge output: %w", err)
+		}
+		return Badge{Count: max(0, payload.Count), Dot: payload.Dot}, nil
+	}
+
+	switch strings.ToLower(text) {
+	case "", "false", "none":
+		return Badge{}, nil
+	case "dot", "true":
+		return Badge{Dot: true}, nil
+	}
+	count, err := strconv.Atoi(text)
+	if err != nil {
+		return Badge{}, errors.New("badge output must be a number, \"dot\", or empty")
+	}
+	return Badge{Count: max(0, count)}, nil
+}
+
+func badgeText(count int) string {
+	if count > 99 {
+		return "99+"
+	}
+	return strconv.Itoa(count)
+}
+
+// withBadge draws badge over the icon, returning data unchanged when the
+// badge is hidden or the icon cannot be decoded. The badge is drawn on the
+// normalized icon, which is size-checked and rasterized from SVG.
+func withBadge(data []byte, badge Badge) ([]byte, error) {
+	if !badge.visible() {
+		return data, nil
+	}
+	img, err := decodeIcon(normalizedIcon(data))
+	if err != nil {
+		return data, err
+	}
+	return encodePNG(drawBadge(img, badge))
+}
+
+func drawBadge(src image.Image, badge Badge) *image.NRGBA {
+	img := toNRGBA(src)
+	width := float64(img.Bounds().Dx())
+	size := float64(min(img.Bounds().Dx(), img.Bounds().Dy()))
+	outline := max(1, size/32)
+	fill := badge.Color
+	if fill.A == 0 {
+		fill = defaultBadgeColor
+	}
+
+	if badge.Count <= 0 {
+		radius := size * 0.22
+		cx := width - radius - outline
+		drawPill(img, cx, cx, radius+outline, radius, outline, fill)
+		return img
+	}
+
+	text := badgeText(badge.Count)
+	height := math.Round(size * 0.5)
+	pad := max(1, math.Floor(height/8))
+	scale := max(1, math.Floor((height-2*pad)/glyphHeight))
+	textWidth := float64(len(text)*glyphWidth+len(text)-1) * scale
+	pillWidth := max(height, textWidth+2*pad+scale)
+
+	radius := height / 2
+	x1 := width - outline - radius
+	x0 := max(radius+outline, x1-(pillWidth-height))
+	cy := outline + radius
+	drawPill(img, x0, x1, cy, radius, outline, fill)
+
+	ink := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	if 0.299*float64(fill.R)+0.587*float64(fill.G)+0.114*float64(fill.B) > 170 {
+		ink = color.NRGBA{R: 0x21, G: 0x25, B: 0x29, A: 0xff}
+	}
+	left := int(math.Round((x0+x1)/2 - textWidth/2))
+	top := int(math.Round(cy - glyphHeight*scale/2))
+	for idx, ch := range text {
+		drawGlyph(img, glyphs[ch], left+idx*(glyphWidth+1)*int(scale), top, int(scale), ink)
+	}
+	return img
+}
+
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+)
+
+// glyphs is a 3x5 pixel font covering the characters used in badge counts.
+// Each row holds three bits, most significant bit on the left.
+var glyphs = map[rune][glyphHeight]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'+': {0, 2, 7, 2, 0},
+}
+
+func drawGlyph(img *image.NRGBA, rows [glyphHeight]uint8, left, top, scale int, ink color.NRGBA) {
+	for row := 0; row < glyphHeight; row++ {
+		for col := 0; col < glyphWidth; col++ {
+			if rows[row]&(1<<(glyphWidth-1-col)) == 0 {
+				continue
+			}
+			for y := top + row*scale; y < top+(row+1)*scale; y++ {
+				for x := left + col*scale; x < left+(col+1)*scale; x++ {
+					if image.Pt(x, y).In(img.Bounds()) {
+						img.SetNRGBA(x, y, ink)
+					}
+				}
+			}
+		}
+	}
+}
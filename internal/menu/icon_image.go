@@ -0,0 +1,408 @@
+package menu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+const (
+	// maxIconBytes and maxIconDimension bound the icons accepted from
+	// Tactical RMM and the local configuration.
+	maxIconBytes     = 4 << 20
+	maxIconDimension = 4096
+)
+
+// normalizeIconPNG converts an ICO, PNG, GIF, JPEG, or simple SVG icon to a
+// square PNG no larger than size pixels. Square PNGs that already fit are
+// returned unchanged.
+func normalizeIconPNG(data []byte, size int) ([]byte, error) {
+	if len(data) > maxIconBytes {
+		return nil, fmt.Errorf("icon is %d bytes, larger than the %d byte limit", len(data), maxIconBytes)
+	}
+	if isSVG(data) {
+		img, err := rasterizeSVG(data, size)
+		if err != nil {
+			return nil, err
+		}
+		return encodePNG(img)
+	}
+	if !isICO(data) {
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("unrecognised image format: %w", err)
+		}
+		if err := checkIconDimensions(cfg.Width, cfg.Height); err != nil {
+			return nil, err
+		}
+		if format == "png" && cfg.Width == cfg.Height && cfg.Width <= size {
+			return data, nil
+		}
+	}
+	img, err := decodeIcon(data)
+	if err != nil {
+		return nil, err
+	}
+	return encodePNG(fitIcon(img, size))
+}
+
+func checkIconDimensions(width, height int) error {
+	if width <= 0 || height <= 0 || width > maxIconDimension || height > maxIconDimension {
+		return fmt.Errorf("icon is %dx%d pixels; the limit is %dx%d", width, height, maxIconDimension, maxIconDimension)
+	}
+	return nil
+}
+
+// fitIcon scales src down to fit within size pixels, keeping its aspect
+// ratio, and centres it on a transparent square canvas.
+func fitIcon(src image.Image, size int) *image.NRGBA {
+	img := toNRGBA(src)
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if longest := max(width, height); longest > size {
+		width = max(1, int(math.Round(float64(width)*float64(size)/float64(longest))))
+		height = max(1, int(math.Round(float64(height)*float64(size)/float64(longest))))
+		img = resizeImage(img, width, height)
+	}
+	if width == height {
+		return img
+	}
+	side := max(width, height)
+	canvas := image.NewNRGBA(image.Rect(0, 0, side, side))
+	left, top := (side-width)/2, (side-height)/2
+	for y := 0; y < height; y++ {
+		copy(canvas.Pix[(top+y)*canvas.Stride+left*4:], img.Pix[y*img.Stride:y*img.Stride+width*4])
+	}
+	return canvas
+}
+
+// resizeImage scales img to width×height by averaging the source area that
+// covers each destination pixel, using premultiplied alpha so transparent
+// pixels do not darken the edges.
+func resizeImage(img *image.NRGBA, width, height int) *image.NRGBA {
+	srcW, srcH := img.Bounds().Dx(), img.Bounds().Dy()
+	pixels := make([]float64, srcW*srcH*4)
+	for y := 0; y < srcH; y++ {
+		for x := 0; x < srcW; x++ {
+			px := img.Pix[y*img.Stride+x*4:]
+			alpha := float64(px[3]) / 255
+			idx := (y*srcW + x) * 4
+			pixels[idx] = float64(px[0]) * alpha
+			pixels[idx+1] = float64(px[1]) * alpha
+			pixels[idx+2] = float64(px[2]) * alpha
+			pixels[idx+3] = float64(px[3])
+		}
+	}
+
+	rows := resampleAxis(pixels, srcW, srcH, width, true)
+	scaled := resampleAxis(rows, width, srcH, height, false)
+
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for idx := 0; idx < width*height; idx++ {
+		alpha := scaled[idx*4+3]
+		if alpha <= 0 {
+			continue
+		}
+		scale := 255 / alpha
+		out.Pix[idx*4] = uint8(math.Min(255, math.Round(scaled[idx*4]*scale)))
+		out.Pix[idx*4+1] = uint8(math.Min(255, math.Round(scaled[idx*4+1]*scale)))
+		out.Pix[idx*4+2] = uint8(math.Min(255, math.Round(scaled[idx*4+2]*scale)))
+		out.Pix[idx*4+3] = uint8(math.Min(255, math.Round(alpha)))
+	}
+	return out
+}
+
+// resampleAxis area-averages a width×height buffer of four channels along
+// one axis, producing target columns (horizontal) or rows.
+func resampleAxis(src []float64, width, height, target int, horizontal bool) []float64 {
+	length, lines := height, width
+	outW, outH := width, target
+	if horizontal {
+		length, lines = width, height
+		outW, outH = target, height
+	}
+	out := make([]float64, outW*outH*4)
+	ratio := float64(length) / float64(target)
+
+	for line := 0; line < lines; line++ {
+		for pos := 0; pos < target; pos++ {
+			start, end := float64(pos)*ratio, float64(pos+1)*ratio
+			var sum [4]float64
+			for src0 := int(start); src0 < length && float64(src0) < end; src0++ {
+				weight := math.Min(end, float64(src0+1)) - math.Max(start, float64(src0))
+				in := (line*width + src0) * 4
+				if !horizontal {
+					in = (src0*width + line) * 4
+				}
+				for ch := 0; ch < 4; ch++ {
+					sum[ch] += src[in+ch] * weight
+				}
+			}
+			dst := (line*outW + pos) * 4
+			if !horizontal {
+				dst = (pos*outW + line) * 4
+			}
+			for ch := 0; ch < 4; ch++ {
+				out[dst+ch] = sum[ch] / ratio
+			}
+		}
+	}
+	return out
+}
+
+// decodeIcon decodes PNG, GIF, and JPEG images as well as ICO containers
+// holding PNG or uncompressed 24/32-bit bitmap entries. For ICO files the
+// largest entry is used.
+// decodeIcon decodes an ICO, PNG, GIF, or JPEG icon. The dimensions are
+// checked before any pixels are allocated.
+func decodeIcon(data []byte) (image.Image, error) {
+	if len(data) > maxIconBytes {
+		return nil, fmt.Errorf("icon is %d bytes, larger than the %d byte limit", len(data), maxIconBytes)
+	}
+	if isICO(data) {
+		return decodeICO(data)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIconDimensions(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func isICO(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	return data[0] == 0x00 && data[1] == 0x00 && data[2] == 0x01 && data[3] == 0x00
+}
+
+func decodeICO(data []byte) (image.Image, error) {
+	if len(data) < 6 {
+		return nil, errors.New("ico: truncated header")
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if count == 0 || len(data) < 6+count*16 {
+		return nil, errors.New("ico: truncated directory")
+	}
+
+	bestSize, bestOffset, bestLength := -1, 0, 0
+	for idx := 0; idx < count; idx++ {
+		entry := data[6+idx*16 : 6+(idx+1)*16]
+		width := int(entry[0])
+		if width == 0 {
+			width = 256
+		}
+		length := int(binary.LittleEndian.Uint32(entry[8:12]))
+		offset := int(binary.LittleEndian.Uint32(entry[12:16]))
+		if offset < 0 || length <= 0 || offset+length > len(data) {
+			continue
+		}
+		if width > bestSize {
+			bestSize, bestOffset, bestLength = width, offset, length
+		}
+	}
+	if bestSize < 0 {
+		return nil, errors.New("ico: no usable entries")
+	}
+
+	payload := data[bestOffset : bestOffset+bestLength]
+	if bytes.HasPrefix(payload, pngSignature) {
+		cfg, err := png.DecodeConfig(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		if err := checkIconDimensions(cfg.Width, cfg.Height); err != nil {
+			return nil, err
+		}
+		return png.Decode(bytes.NewReader(payload))
+	}
+	return decodeDIB(payload)
+}
+
+// decodeDIB decodes the bitmap stored in an ICO entry: a BITMAPINFOHEADER
+// followed by bottom-up pixel rows, with the height doubled to cover the
+// AND mask.
+func decodeDIB(payload []byte) (image.Image, error) {
+	if len(payload) < 40 {
+		return nil, errors.New("ico: truncated bitmap header")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(payload[0:4]))
+	width := int(int32(binary.LittleEndian.Uint32(payload[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(payload[8:12]))) / 2
+	bpp := int(binary.LittleEndian.Uint16(payload[14:16]))
+	compression := binary.LittleEndian.Uint32(payload[16:20])
+	if width <= 0 || height <= 0 || headerSize < 40 || compression != 0 {
+		return nil, errors.New("ico: unsupported bitmap")
+	}
+	if headerSize > len(payload) {
+		return nil, errors.New("ico: truncated bitmap header")
+	}
+	if bpp != 32 && bpp != 24 {
+		return nil, fmt.Errorf("ico: unsupported bit depth %d", bpp)
+	}
+	if err := checkIconDimensions(width, height); err != nil {
+		return nil, err
+	}
+
+	// The dimensions come from the file, so the rows of 4-byte aligned
+	// pixels must fit in what is left of the entry before any is read.
+	stride := ((width*bpp + 31) / 32) * 4
+	pixels := payload[headerSize:]
+	if len(pixels) < stride*height {
+		return nil, errors.New("ico: truncated bitmap data")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := pixels[(height-1-y)*stride:]
+		for x := 0; x < width; x++ {
+			px := row[x*bpp/8:]
+			c := color.NRGBA{R: px[2], G: px[1], B: px[0], A: 0xff}
+			if bpp == 32 {
+				c.A = px[3]
+				hasAlpha = hasAlpha || c.A != 0
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	// Bitmaps without an alpha channel rely on the 1-bit AND mask that
+	// follows the colour data for transparency.
+	maskStride := ((width + 31) / 32) * 4
+	mask := pixels[stride*height:]
+	if !hasAlpha && len(mask) >= maskStride*height {
+		for y := 0; y < height; y++ {
+			row := mask[(height-1-y)*maskStride:]
+			for x := 0; x < width; x++ {
+				transparent := row[x/8]&(0x80>>(x%8)) != 0
+				c := img.NRGBAAt(x, y)
+				if transparent {
+					c.A = 0
+				} else {
+					c.A = 0xff
+				}
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+	return img, nil
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// withStatusDot draws a filled circle with a light outline in the bottom
+// right corner of the icon.
+func withStatusDot(src image.Image, fill color.NRGBA) *image.NRGBA {
+	img := toNRGBA(src)
+	size := min(img.Bounds().Dx(), img.Bounds().Dy())
+	radius := float64(size) * 0.22
+	outline := max(1, float64(size)/32)
+	cx := float64(img.Bounds().Dx()) - radius - outline
+	cy := float64(img.Bounds().Dy()) - radius - outline
+	drawPill(img, cx, cx, cy, radius, outline, fill)
+	return img
+}
+
+// drawPill draws an anti-aliased capsule whose centre line runs from x0 to
+// x1 at height cy, surrounded by a white outline. Equal x0 and x1 give a
+// circle.
+func drawPill(img *image.NRGBA, x0, x1, cy, radius, outline float64, fill color.NRGBA) {
+	border := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	outer := radius + outline
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			dx := px - max(x0, min(x1, px))
+			dy := py - cy
+			dist := dx*dx + dy*dy
+			if dist > (outer+1)*(outer+1) {
+				continue
+			}
+			d := math.Sqrt(dist)
+			if cover := clamp01(outer + 0.5 - d); cover > 0 {
+				blend(img, x, y, border, cover)
+			}
+			if cover := clamp01(radius + 0.5 - d); cover > 0 {
+				blend(img, x, y, fill, cover)
+			}
+		}
+	}
+}
+
+// desaturate converts the icon to faded greyscale.
+func desaturate(src image.Image) *image.NRGBA {
+	img := toNRGBA(src)
+	for idx := 0; idx+3 < len(img.Pix); idx += 4 {
+		r, g, b := float64(img.Pix[idx]), float64(img.Pix[idx+1]), float64(img.Pix[idx+2])
+		luma := uint8(0.299*r + 0.587*g + 0.114*b)
+		img.Pix[idx], img.Pix[idx+1], img.Pix[idx+2] = luma, luma, luma
+		img.Pix[idx+3] = uint8(float64(img.Pix[idx+3]) * 0.6)
+	}
+	return img
+}
+
+func blend(img *image.NRGBA, x, y int, c color.NRGBA, cover float64) {
+	dst := img.NRGBAAt(x, y)
+	srcA := float64(c.A) / 255 * cover
+	dstA := float64(dst.A) / 255
+	outA := srcA + dstA*(1-srcA)
+	if outA <= 0 {
+		return
+	}
+	mix := func(s, d uint8) uint8 {
+		return uint8((float64(s)*srcA + float64(d)*dstA*(1-srcA)) / outA)
+	}
+	img.SetNRGBA(x, y, color.NRGBA{R: mix(c.R, dst.R), G: mix(c.G, dst.G), B: mix(c.B, dst.B), A: uint8(outA * 255)})
+}
+
+// parseHexColor parses "#rgb" or "#rrggbb"; the leading "#" is optional.
+func parseHexColor(value string) (color.NRGBA, bool) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.NRGBA{}, false
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}, true
+}
+
+func clamp01(v float64) float64 {
+	return max(0, min(1, v))
+}
@@ -0,0 +1,142 @@
+package menu
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeIconPNGResizesAndPadsToSquare(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for idx := 0; idx < len(src.Pix); idx += 4 {
+		src.Pix[idx], src.Pix[idx+3] = 0xd0, 0xff
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := normalizeIconPNG(buf.Bytes(), 64)
+	if err != nil {
+		t.Fatalf("normalize jpeg: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected png output: %v", err)
+	}
+	if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 64 {
+		t.Fatalf("unexpected size %v", img.Bounds())
+	}
+	if _, _, _, a := img.At(32, 2).RGBA(); a != 0 {
+		t.Fatalf("expected transparent padding above the image")
+	}
+	if got := color.NRGBAModel.Convert(img.At(32, 32)).(color.NRGBA); got.A != 0xff || got.R < 0xc0 {
+		t.Fatalf("unexpected centre pixel %+v", got)
+	}
+}
+
+func TestNormalizeIconPNGKeepsSmallSquarePNG(t *testing.T) {
+	data := testIcon(t, 32, color.NRGBA{B: 0xff, A: 0xff})
+	got, err := normalizeIconPNG(data, 64)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("expected the icon to be returned unchanged, err=%v", err)
+	}
+}
+
+func TestNormalizeIconPNGRejectsUnusableIcons(t *testing.T) {
+	wide, err := encodePNG(image.NewNRGBA(image.Rect(0, 0, maxIconDimension+1, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string][]byte{
+		"too many bytes": make([]byte, maxIconBytes+1),
+		"too wide":       wide,
+		"not an image":   []byte("definitely not an icon"),
+	}
+	for name, data := range cases {
+		if _, err := normalizeIconPNG(data, 64); err == nil {
+			t.Fatalf("%s: expected the icon to be rejected", name)
+		}
+	}
+}
+
+func TestRasterizeSVG(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24">
+  <defs><linearGradient id="g"/></defs>
+  <rect width="24" height="24" fill="none"/>
+  <path fill-rule="evenodd" style="fill:#2f80ed" d="M2 2h20v20H2z M8 8v8h8V8z"/>
+  <g transform="translate(12 12)" fill="red" opacity="0.5">
+    <circle r="2"/>
+  </g>
+  <path d="M0,24a3 3 0 013-3v3z"/>
+</svg>`
+
+	data, err := normalizeIconPNG([]byte(doc), 48)
+	if err != nil {
+		t.Fatalf("rasterize svg: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(x, y int) color.NRGBA { return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA) }
+
+	if got := at(6, 6); got != (color.NRGBA{R: 0x2f, G: 0x80, B: 0xed, A: 0xff}) {
+		t.Fatalf("expected the outer square to be filled, got %+v", got)
+	}
+	if got := at(20, 20); got.A != 0 {
+		t.Fatalf("expected the even-odd hole to stay transparent, got %+v", got)
+	}
+	if got := at(24, 24); got.R != 0xff || got.A < 0x70 || got.A > 0x90 {
+		t.Fatalf("expected a half-transparent red circle in the centre, got %+v", got)
+	}
+	if got := at(1, 46); got.A != 0xff || got.R != 0 {
+		t.Fatalf("expected the arc to fill the bottom left corner, got %+v", got)
+	}
+	if got := at(1, 1); got.A != 0 {
+		t.Fatalf("expected the corner to stay transparent, got %+v", got)
+	}
+
+	for _, bad := range []string{
+		`<svg viewBox="0 0 10 10"><rect width="10" height="10" fill="none"/></svg>`,
+		`<svg><rect width="10" height="10"/></svg>`,
+		`<svg viewBox="0 0 10 10"><path d="M0 0 L"/></svg>`,
+		`<svg viewBox="0 0 10 10"><path d="M0 0 L5 5 L0 5 Z 3"/></svg>`,
+	} {
+		if _, err := rasterizeSVG([]byte(bad), 16); err == nil || !strings.HasPrefix(err.Error(), "svg:") {
+			t.Fatalf("expected %q to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+// FuzzParseSVGPath checks that path data from untrusted icons can neither
+// panic nor hang the parser.
+func FuzzParseSVGPath(f *testing.F) {
+	for _, seed := range []string{
+		"M0 0 L5 5 L0 5 Z 3",
+		"M2 2h20v20H2z M8 8v8h8V8z",
+		"M0,24a3 3 0 013-3v3z",
+		"m1 1 2 2 3 3zM0 0",
+		"M0 0 C1 1 2 2 3 3 S4 4 5 5 Q6 6 7 7 T8 8",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, d string) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			parseSVGPath(d)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("parseSVGPath(%q) did not return", d)
+		}
+	})
+}
@@ -2,6 +2,30 @@
 
 package menu
 
+import (
+	"runtime"
+
+	"github.com/example/gotray/internal/logging"
+)
+
+// trayIconSize is the largest icon handed to the tray. macOS draws tray and
+// menu icons at 16 points, so 32 pixels covers Retina displays; Linux panels
+// scale the icon file themselves.
+func trayIconSize() int {
+	if runtime.GOOS == "darwin" {
+		return 32
+	}
+	return 64
+}
+
 func platformNormalizeIcon(data []byte) []byte {
-	return data
+	if len(data) == 0 {
+		return nil
+	}
+	normalized, err := normalizeIconPNG(data, trayIconSize())
+	if err != nil {
+		logging.Debugf("rejected tray icon: %v", err)
+		return nil
+	}
+	return normalized
 }
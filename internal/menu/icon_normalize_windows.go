@@ -22,6 +22,27 @@ func platformNormalizeIcon(data []byte) []byte {
 		return data
 	}
 
+	if isSVG(data) {
+		rendered, err := normalizeIconPNG(data, 32)
+		if err != nil {
+			logging.Debugf("failed to render svg tray icon: %v", err)
+			return nil
+		}
+		data = rendered
+	}
+
+	if len(data) > maxIconBytes {
+		logging.Debugf("tray icon is %d bytes, larger than the %d byte limit", len(data), maxIconBytes)
+		return nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err == nil {
+		err = checkIconDimensions(cfg.Width, cfg.Height)
+	}
+	if err != nil {
+		logging.Debugf("rejected tray icon: %v", err)
+		return nil
+	}
 	img, format, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		logging.Debugf("failed to decode tray icon image: %v", err)
@@ -109,10 +130,3 @@ func wrapPNGAsICO(pngData []byte, width, height int) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
-
-func isICO(data []byte) bool {
-	if len(data) < 4 {
-		return false
-	}
-	return data[0] == 0x00 && data[1] == 0x00 && data[2] == 0x01 && data[3] == 0x00
-}
@@ -0,0 +1,103 @@
+package menu
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/example/gotray/internal/logging"
+)
+
+// IconState describes the sync health shown by the tray icon.
+type IconState string
+
+const (
+	IconNormal  IconState = "normal"
+	IconSyncing IconState = "syncing"
+	IconWarning IconState = "warning"
+	IconError   IconState = "error"
+	IconOffline IconState = "offline"
+)
+
+// CustomizableIconStates lists the states whose icon can be replaced through
+// the stateIcons setting. The normal state always uses the configured icon.
+var CustomizableIconStates = []IconState{IconSyncing, IconWarning, IconError, IconOffline}
+
+// ParseIconState validates the name of a customisable icon state.
+func ParseIconState(name string) (IconState, error) {
+	for _, state := range CustomizableIconStates {
+		if strings.EqualFold(strings.TrimSpace(name), string(state)) {
+			return state, nil
+		}
+	}
+	names := make([]string, len(CustomizableIconStates))
+	for idx, state := range CustomizableIconStates {
+		names[idx] = string(state)
+	}
+	return "", fmt.Errorf("unknown icon state %q; expected one of %s", name, strings.Join(names, ", "))
+}
+
+var stateDotColours = map[IconState]color.NRGBA{
+	IconSyncing: {R: 0x2f, G: 0x80, B: 0xed, A: 0xff},
+	IconWarning: {R: 0xf2, G: 0xa9, B: 0x00, A: 0xff},
+	IconError:   {R: 0xe0, G: 0x31, B: 0x31, A: 0xff},
+}
+
+// iconVariants caches the generated state icons for the current base icon.
+type iconVariants struct {
+	mu    sync.Mutex
+	base  []byte
+	icons map[IconState][]byte
+}
+
+// icon returns the image shown for state: a configured icon file when one is
+// set, otherwise a variant generated from base. Errors fall back to base.
+func (v *iconVariants) icon(base []byte, state IconState, custom map[string]string) []byte {
+	if state == "" || state == IconNormal {
+		return base
+	}
+	if path := strings.TrimSpace(custom[string(state)]); path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil && len(data) > 0 {
+			return data
+		}
+		logging.Debugf("failed to read %s tray icon %s: %v", state, path, err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.icons == nil || !bytes.Equal(v.base, base) {
+		v.base = cloneIcon(base)
+		v.icons = make(map[IconState][]byte)
+	}
+	if cached, ok := v.icons[state]; ok {
+		return cached
+	}
+	// Variants are drawn on the normalized icon, which is size-checked and
+	// already rasterized when the configured icon is an SVG.
+	generated, err := generateStateIcon(normalizedIcon(base), state)
+	if err != nil {
+		logging.Debugf("failed to generate %s tray icon: %v", state, err)
+		generated = base
+	}
+	v.icons[state] = generated
+	return generated
+}
+
+func generateStateIcon(base []byte, state IconState) ([]byte, error) {
+	img, err := decodeIcon(base)
+	if err != nil {
+		return nil, err
+	}
+	if state == IconOffline {
+		return encodePNG(desaturate(img))
+	}
+	fill, ok := stateDotColours[state]
+	if !ok {
+		return nil, fmt.Errorf("no indicator defined for state %q", state)
+	}
+	return encodePNG(withStatusDot(img, fill))
+}
@@ -0,0 +1,165 @@
+package menu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testIcon(t *testing.T, size int, fill color.NRGBA) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.SetNRGBA(x, y, fill)
+		}
+	}
+	data, err := encodePNG(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeIconReadsICOEntries(t *testing.T) {
+	payload := testIcon(t, 16, color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff})
+
+	var ico bytes.Buffer
+	binary.Write(&ico, binary.LittleEndian, []uint16{0, 1, 1})
+	ico.Write([]byte{16, 16, 0, 0})
+	binary.Write(&ico, binary.LittleEndian, []uint16{1, 32})
+	binary.Write(&ico, binary.LittleEndian, []uint32{uint32(len(payload)), 22})
+	ico.Write(payload)
+
+	img, err := decodeIcon(ico.Bytes())
+	if err != nil {
+		t.Fatalf("decode ico: %v", err)
+	}
+	if got := color.NRGBAModel.Convert(img.At(4, 4)).(color.NRGBA); got.B != 0x30 || got.A != 0xff {
+		t.Fatalf("unexpected pixel %+v", got)
+	}
+
+	// A 2x1 32-bit bitmap entry stored bottom-up with an AND mask.
+	dib := make([]byte, 40)
+	binary.LittleEndian.PutUint32(dib[0:4], 40)
+	binary.LittleEndian.PutUint32(dib[4:8], 2)
+	binary.LittleEndian.PutUint32(dib[8:12], 2)
+	binary.LittleEndian.PutUint16(dib[12:14], 1)
+	binary.LittleEndian.PutUint16(dib[14:16], 32)
+	dib = append(dib, 0xff, 0x00, 0x00, 0xff, 0x00, 0x00, 0xff, 0x80)
+	dib = append(dib, 0, 0, 0, 0)
+	img, err = decodeDIB(dib)
+	if err != nil {
+		t.Fatalf("decode dib: %v", err)
+	}
+	if got := img.(*image.NRGBA).NRGBAAt(1, 0); got.R != 0xff || got.A != 0x80 {
+		t.Fatalf("unexpected bitmap pixel %+v", got)
+	}
+}
+
+func TestDecodeDIBRejectsMalformedBitmaps(t *testing.T) {
+	header := func(headerSize, width, height uint32) []byte {
+		dib := make([]byte, 40)
+		binary.LittleEndian.PutUint32(dib[0:4], headerSize)
+		binary.LittleEndian.PutUint32(dib[4:8], width)
+		binary.LittleEndian.PutUint32(dib[8:12], height)
+		binary.LittleEndian.PutUint16(dib[12:14], 1)
+		binary.LittleEndian.PutUint16(dib[14:16], 32)
+		return dib
+	}
+
+	cases := map[string][]byte{
+		"header beyond entry": append(header(1000, 2, 2), make([]byte, 8)...),
+		"truncated pixels":    append(header(40, 16, 32), make([]byte, 48)...),
+		"oversized":           append(header(40, 1<<20, 1<<21), make([]byte, 8)...),
+	}
+	for name, dib := range cases {
+		if _, err := decodeDIB(dib); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+
+		var ico bytes.Buffer
+		binary.Write(&ico, binary.LittleEndian, []uint16{0, 1, 1})
+		ico.Write([]byte{16, 16, 0, 0})
+		binary.Write(&ico, binary.LittleEndian, []uint16{1, 32})
+		binary.Write(&ico, binary.LittleEndian, []uint32{uint32(len(dib)), 22})
+		ico.Write(dib)
+		if _, err := decodeIcon(ico.Bytes()); err == nil {
+			t.Fatalf("%s: expected the icon to be rejected", name)
+		}
+	}
+}
+
+func TestIconVariantsGeneratesStateIndicators(t *testing.T) {
+	base := testIcon(t, 32, color.NRGBA{R: 0x20, G: 0xa0, B: 0x40, A: 0xff})
+	var variants iconVariants
+
+	if got := variants.icon(base, IconNormal, nil); !bytes.Equal(got, base) {
+		t.Fatalf("expected the base icon for the normal state")
+	}
+
+	errIcon, err := decodeIcon(variants.icon(base, IconError, nil))
+	if err != nil {
+		t.Fatalf("decode error icon: %v", err)
+	}
+	if got := color.NRGBAModel.Convert(errIcon.At(24, 24)).(color.NRGBA); got != stateDotColours[IconError] {
+		t.Fatalf("expected a red indicator in the corner, got %+v", got)
+	}
+	if got := color.NRGBAModel.Convert(errIcon.At(4, 4)).(color.NRGBA); got.G != 0xa0 {
+		t.Fatalf("expected the rest of the icon to be unchanged, got %+v", got)
+	}
+
+	offline, err := decodeIcon(variants.icon(base, IconOffline, nil))
+	if err != nil {
+		t.Fatalf("decode offline icon: %v", err)
+	}
+	if got := color.NRGBAModel.Convert(offline.At(4, 4)).(color.NRGBA); got.R != got.G || got.G != got.B || got.A == 0xff {
+		t.Fatalf("expected a faded greyscale icon, got %+v", got)
+	}
+
+	custom := filepath.Join(t.TempDir(), "error.png")
+	customIcon := testIcon(t, 16, stateDotColours[IconError])
+	if err := os.WriteFile(custom, customIcon, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := variants.icon(base, IconError, map[string]string{"error": custom}); !bytes.Equal(got, customIcon) {
+		t.Fatalf("expected the configured error icon")
+	}
+}
+
+func TestIconVariantsUseNormalizedIcon(t *testing.T) {
+	wide, err := encodePNG(image.NewNRGBA(image.Rect(0, 0, maxIconDimension+1, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateStateIcon(wide, IconError); err == nil {
+		t.Fatalf("expected an oversized icon to be rejected before decoding")
+	}
+
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><rect width="24" height="24" fill="#20a040"/></svg>`)
+	var variants iconVariants
+	img, err := decodeIcon(variants.icon(svg, IconError, nil))
+	if err != nil {
+		t.Fatalf("expected a state variant of the SVG icon: %v", err)
+	}
+	size := img.Bounds().Dx()
+	if got := color.NRGBAModel.Convert(img.At(size*3/4, size*3/4)).(color.NRGBA); got != stateDotColours[IconError] {
+		t.Fatalf("expected a red indicator in the corner, got %+v", got)
+	}
+	if got := color.NRGBAModel.Convert(img.At(2, 2)).(color.NRGBA); got.G != 0xa0 {
+		t.Fatalf("expected the SVG to be drawn below the indicator, got %+v", got)
+	}
+}
+
+func TestParseIconState(t *testing.T) {
+	if state, err := ParseIconState(" Warning "); err != nil || state != IconWarning {
+		t.Fatalf("unexpected result %q, %v", state, err)
+	}
+	if _, err := ParseIconState("normal"); err == nil {
+		t.Fatalf("expected the normal state to be rejected")
+	}
+}
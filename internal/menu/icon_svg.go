@@ -0,0 +1,743 @@
+package menu
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// svgSamples is the number of scanlines sampled per pixel row.
+const svgSamples = 4
+
+// isSVG reports whether data looks like an SVG document.
+func isSVG(data []byte) bool {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return bytes.Contains(bytes.ToLower(head), []byte("<svg"))
+}
+
+type svgPoint struct{ x, y float64 }
+
+// svgMatrix is an affine transform [a c e; b d f].
+type svgMatrix struct{ a, b, c, d, e, f float64 }
+
+var svgIdentity = svgMatrix{a: 1, d: 1}
+
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		a: m.a*n.a + m.c*n.b,
+		b: m.b*n.a + m.d*n.b,
+		c: m.a*n.c + m.c*n.d,
+		d: m.b*n.c + m.d*n.d,
+		e: m.a*n.e + m.c*n.f + m.e,
+		f: m.b*n.e + m.d*n.f + m.f,
+	}
+}
+
+func (m svgMatrix) apply(p svgPoint) svgPoint {
+	return svgPoint{x: m.a*p.x + m.c*p.y + m.e, y: m.b*p.x + m.d*p.y + m.f}
+}
+
+// svgStyle holds the inherited presentation attributes that affect fills.
+type svgStyle struct {
+	fill    color.NRGBA
+	noFill  bool
+	opacity float64
+	evenOdd bool
+	hidden  bool
+}
+
+type svgFrame struct {
+	style     svgStyle
+	transform svgMatrix
+	skip      bool
+}
+
+// rasterizeSVG renders the filled shapes of a simple SVG document (rect,
+// circle, ellipse, polygon, and path elements in groups with transforms)
+// into a size×size image. Strokes, gradients, and text are not supported.
+func rasterizeSVG(data []byte, size int) (*image.NRGBA, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	var stack []svgFrame
+	var view svgMatrix
+	sawRoot, drawn := false, false
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse svg: %w", err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			parent := svgFrame{style: svgStyle{fill: color.NRGBA{A: 0xff}, opacity: 1}, transform: svgIdentity}
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			frame := parent
+			attrs := svgAttributes(el.Attr)
+			name := strings.ToLower(el.Name.Local)
+
+			if !sawRoot {
+				if name != "svg" {
+					return nil, errors.New("svg: missing <svg> root element")
+				}
+				sawRoot = true
+				view, err = svgViewport(attrs, size)
+				if err != nil {
+					return nil, err
+				}
+				frame.transform = view
+			}
+			switch name {
+			case "defs", "clippath", "mask", "symbol", "pattern", "lineargradient", "radialgradient", "style", "title", "desc", "metadata", "text", "marker", "filter":
+				frame.skip = true
+			}
+			stack = append(stack, frame)
+			if frame.skip {
+				continue
+			}
+
+			top := &stack[len(stack)-1]
+			if err := top.style.apply(attrs); err != nil {
+				return nil, err
+			}
+			if raw := attrs["transform"]; raw != "" {
+				transform, err := parseSVGTransform(raw)
+				if err != nil {
+					return nil, err
+				}
+				top.transform = top.transform.mul(transform)
+			}
+			if top.style.hidden || top.style.noFill {
+				continue
+			}
+
+			paths, err := svgShapePaths(name, attrs)
+			if err != nil {
+				return nil, err
+			}
+			if len(paths) == 0 {
+				continue
+			}
+			for _, path := range paths {
+				for idx := range path {
+					path[idx] = top.transform.apply(path[idx])
+				}
+			}
+			fill := top.style.fill
+			fill.A = uint8(float64(fill.A) * clamp01(top.style.opacity))
+			fillPaths(img, paths, fill, top.style.evenOdd)
+			drawn = true
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if !sawRoot {
+		return nil, errors.New("svg: missing <svg> root element")
+	}
+	if !drawn {
+		return nil, errors.New("svg: no filled shapes to render")
+	}
+	return img, nil
+}
+
+func svgAttributes(attrs []xml.Attr) map[string]string {
+	out := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		out[strings.ToLower(attr.Name.Local)] = strings.TrimSpace(attr.Value)
+	}
+	// Declarations in the style attribute take precedence over attributes.
+	for _, decl := range strings.Split(out["style"], ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if ok {
+			out[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+	return out
+}
+
+// svgViewport maps the document's viewBox, or its width and height, onto a
+// size×size image, preserving the aspect ratio and centring the content.
+func svgViewport(attrs map[string]string, size int) (svgMatrix, error) {
+	var minX, minY, width, height float64
+	if box := svgNumbers(attrs["viewbox"]); len(box) == 4 {
+		minX, minY, width, height = box[0], box[1], box[2], box[3]
+	} else {
+		width, _ = svgLength(attrs["width"])
+		height, _ = svgLength(attrs["height"])
+	}
+	if width <= 0 || height <= 0 {
+		return svgMatrix{}, errors.New("svg: a viewBox or width and height are required")
+	}
+	scale := float64(size) / max(width, height)
+	return svgMatrix{
+		a: scale,
+		d: scale,
+		e: -minX*scale + (float64(size)-width*scale)/2,
+		f: -minY*scale + (float64(size)-height*scale)/2,
+	}, nil
+}
+
+func (s *svgStyle) apply(attrs map[string]string) error {
+	if value, ok := attrs["fill"]; ok {
+		switch {
+		case value == "none" || value == "transparent":
+			s.noFill = true
+		case strings.HasPrefix(value, "url("):
+			// Gradients and patterns are drawn in the default colour so the
+			// shape stays visible.
+			s.noFill = false
+			s.fill = color.NRGBA{A: 0xff}
+		case value == "inherit":
+		default:
+			fill, err := parseSVGColor(value)
+			if err != nil {
+				return err
+			}
+			s.fill = fill
+			s.noFill = false
+		}
+	}
+	for _, key := range []string{"opacity", "fill-opacity"} {
+		if value, ok := attrs[key]; ok {
+			opacity, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+			if err != nil {
+				return fmt.Errorf("svg: invalid %s %q", key, value)
+			}
+			if strings.HasSuffix(value, "%") {
+				opacity /= 100
+			}
+			s.opacity *= clamp01(opacity)
+		}
+	}
+	if value, ok := attrs["fill-rule"]; ok {
+		s.evenOdd = value == "evenodd"
+	}
+	if attrs["display"] == "none" || attrs["visibility"] == "hidden" {
+		s.hidden = true
+	}
+	return nil
+}
+
+var svgNamedColors = map[string]color.NRGBA{
+	"black":        {A: 0xff},
+	"currentcolor": {A: 0xff},
+	"white":        {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	"red":          {R: 0xff, A: 0xff},
+	"green":        {G: 0x80, A: 0xff},
+	"lime":         {G: 0xff, A: 0xff},
+	"blue":         {B: 0xff, A: 0xff},
+	"yellow":       {R: 0xff, G: 0xff, A: 0xff},
+	"orange":       {R: 0xff, G: 0xa5, A: 0xff},
+	"purple":       {R: 0x80, B: 0x80, A: 0xff},
+	"gray":         {R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+	"grey":         {R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+	"silver":       {R: 0xc0, G: 0xc0, B: 0xc0, A: 0xff},
+}
+
+func parseSVGColor(value string) (color.NRGBA, error) {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	if named, ok := svgNamedColors[lower]; ok {
+		return named, nil
+	}
+	if strings.HasPrefix(lower, "rgb(") && strings.HasSuffix(lower, ")") {
+		parts := svgNumbers(strings.TrimSuffix(strings.TrimPrefix(lower, "rgb("), ")"))
+		if len(parts) == 3 {
+			return color.NRGBA{R: uint8(clamp01(parts[0]/255) * 255), G: uint8(clamp01(parts[1]/255) * 255), B: uint8(clamp01(parts[2]/255) * 255), A: 0xff}, nil
+		}
+	}
+	if hex, ok := parseHexColor(lower); ok {
+		return hex, nil
+	}
+	return color.NRGBA{}, fmt.Errorf("svg: unsupported colour %q", value)
+}
+
+func parseSVGTransform(raw string) (svgMatrix, error) {
+	result := svgIdentity
+	rest := strings.TrimSpace(raw)
+	for rest != "" {
+		open := strings.Index(rest, "(")
+		end := strings.Index(rest, ")")
+		if open < 0 || end < open {
+			return svgMatrix{}, fmt.Errorf("svg: invalid transform %q", raw)
+		}
+		name := strings.TrimSpace(strings.Trim(rest[:open], ", "))
+		args := svgNumbers(rest[open+1 : end])
+		rest = strings.TrimSpace(strings.TrimLeft(rest[end+1:], ", "))
+
+		var m svgMatrix
+		switch {
+		case name == "matrix" && len(args) == 6:
+			m = svgMatrix{a: args[0], b: args[1], c: args[2], d: args[3], e: args[4], f: args[5]}
+		case name == "translate" && len(args) == 1:
+			m = svgMatrix{a: 1, d: 1, e: args[0]}
+		case name == "translate" && len(args) == 2:
+			m = svgMatrix{a: 1, d: 1, e: args[0], f: args[1]}
+		case name == "scale" && len(args) == 1:
+			m = svgMatrix{a: args[0], d: args[0]}
+		case name == "scale" && len(args) == 2:
+			m = svgMatrix{a: args[0], d: args[1]}
+		case name == "rotate" && (len(args) == 1 || len(args) == 3):
+			sin, cos := math.Sincos(args[0] * math.Pi / 180)
+			m = svgMatrix{a: cos, b: sin, c: -sin, d: cos}
+			if len(args) == 3 {
+				m = svgMatrix{a: 1, d: 1, e: args[1], f: args[2]}.mul(m).mul(svgMatrix{a: 1, d: 1, e: -args[1], f: -args[2]})
+			}
+		default:
+			return svgMatrix{}, fmt.Errorf("svg: unsupported transform %q", raw)
+		}
+		result = result.mul(m)
+	}
+	return result, nil
+}
+
+// svgShapePaths converts a shape element into closed polygons in user space.
+func svgShapePaths(name string, attrs map[string]string) ([][]svgPoint, error) {
+	num := func(key string) float64 {
+		value, _ := svgLength(attrs[key])
+		return value
+	}
+	switch name {
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return nil, nil
+		}
+		rx, ry := num("rx"), num("ry")
+		if _, ok := attrs["ry"]; !ok {
+			ry = rx
+		}
+		if _, ok := attrs["rx"]; !ok {
+			rx = ry
+		}
+		rx, ry = min(rx, w/2), min(ry, h/2)
+		if rx <= 0 || ry <= 0 {
+			return [][]svgPoint{{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}}, nil
+		}
+		var path []svgPoint
+		path = appendEllipseArc(path, x+w-rx, y+ry, rx, ry, -90, 0)
+		path = appendEllipseArc(path, x+w-rx, y+h-ry, rx, ry, 0, 90)
+		path = appendEllipseArc(path, x+rx, y+h-ry, rx, ry, 90, 180)
+		path = appendEllipseArc(path, x+rx, y+ry, rx, ry, 180, 270)
+		return [][]svgPoint{path}, nil
+	case "circle":
+		r := num("r")
+		if r <= 0 {
+			return nil, nil
+		}
+		return [][]svgPoint{appendEllipseArc(nil, num("cx"), num("cy"), r, r, 0, 360)}, nil
+	case "ellipse":
+		rx, ry := num("rx"), num("ry")
+		if rx <= 0 || ry <= 0 {
+			return nil, nil
+		}
+		return [][]svgPoint{appendEllipseArc(nil, num("cx"), num("cy"), rx, ry, 0, 360)}, nil
+	case "polygon", "polyline":
+		values := svgNumbers(attrs["points"])
+		path := make([]svgPoint, 0, len(values)/2)
+		for idx := 0; idx+1 < len(values); idx += 2 {
+			path = append(path, svgPoint{values[idx], values[idx+1]})
+		}
+		return [][]svgPoint{path}, nil
+	case "path":
+		return parseSVGPath(attrs["d"])
+	}
+	return nil, nil
+}
+
+func appendEllipseArc(path []svgPoint, cx, cy, rx, ry, fromDeg, toDeg float64) []svgPoint {
+	steps := max(4, int(math.Ceil(math.Abs(toDeg-fromDeg)/6)))
+	for step := 0; step <= steps; step++ {
+		angle := (fromDeg + (toDeg-fromDeg)*float64(step)/float64(steps)) * math.Pi / 180
+		path = append(path, svgPoint{cx + rx*math.Cos(angle), cy + ry*math.Sin(angle)})
+	}
+	return path
+}
+
+// parseSVGPath flattens path data into polygons. Curves are approximated by
+// line segments.
+func parseSVGPath(d string) ([][]svgPoint, error) {
+	tokens := svgPathTokens(d)
+	var paths [][]svgPoint
+	var current []svgPoint
+	var pos, start, lastControl svgPoint
+	var prevCmd byte
+
+	closePath := func() {
+		if len(current) > 2 {
+			paths = append(paths, current)
+		}
+		current = nil
+	}
+
+	idx := 0
+	next := func(n int) ([]float64, error) {
+		if idx+n > len(tokens) {
+			return nil, fmt.Errorf("svg: truncated path data %q", d)
+		}
+		out := make([]float64, n)
+		for i := 0; i < n; i++ {
+			value, err := strconv.ParseFloat(tokens[idx+i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("svg: invalid path data %q", d)
+			}
+			out[i] = value
+		}
+		idx += n
+		return out, nil
+	}
+
+	var cmd byte
+	for idx < len(tokens) {
+		if tok := tokens[idx]; len(tok) == 1 && strings.ContainsAny(tok, "MmLlHhVvCcSsQqTtAaZz") {
+			cmd = tok[0]
+			idx++
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("svg: invalid path data %q", d)
+		}
+
+		relative := cmd >= 'a'
+		offset := func(p svgPoint) svgPoint {
+			if relative {
+				return svgPoint{p.x + pos.x, p.y + pos.y}
+			}
+			return p
+		}
+
+		switch cmd {
+		case 'Z', 'z':
+			closePath()
+			pos = start
+			prevCmd = cmd
+			// Close path takes no arguments, so a number after it is an
+			// error rather than a repeat of the command.
+			cmd = 0
+			continue
+		case 'M', 'm':
+			args, err := next(2)
+			if err != nil {
+				return nil, err
+			}
+			closePath()
+			pos = offset(svgPoint{args[0], args[1]})
+			start = pos
+			current = []svgPoint{pos}
+			// Further coordinate pairs are implicit line-to commands.
+			if relative {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+		case 'L', 'l':
+			args, err := next(2)
+			if err != nil {
+				return nil, err
+			}
+			pos = offset(svgPoint{args[0], args[1]})
+			current = append(current, pos)
+		case 'H', 'h':
+			args, err := next(1)
+			if err != nil {
+				return nil, err
+			}
+			if relative {
+				pos.x += args[0]
+			} else {
+				pos.x = args[0]
+			}
+			current = append(current, pos)
+		case 'V', 'v':
+			args, err := next(1)
+			if err != nil {
+				return nil, err
+			}
+			if relative {
+				pos.y += args[0]
+			} else {
+				pos.y = args[0]
+			}
+			current = append(current, pos)
+		case 'C', 'c', 'S', 's':
+			var c1, c2, end svgPoint
+			if cmd == 'C' || cmd == 'c' {
+				args, err := next(6)
+				if err != nil {
+					return nil, err
+				}
+				c1, c2, end = offset(svgPoint{args[0], args[1]}), offset(svgPoint{args[2], args[3]}), offset(svgPoint{args[4], args[5]})
+			} else {
+				args, err := next(4)
+				if err != nil {
+					return nil, err
+				}
+				c1 = pos
+				if strings.IndexByte("CcSs", prevCmd) >= 0 {
+					c1 = svgPoint{2*pos.x - lastControl.x, 2*pos.y - lastControl.y}
+				}
+				c2, end = offset(svgPoint{args[0], args[1]}), offset(svgPoint{args[2], args[3]})
+			}
+			current = appendCubic(current, pos, c1, c2, end)
+			lastControl, pos = c2, end
+		case 'Q', 'q', 'T', 't':
+			var ctrl, end svgPoint
+			if cmd == 'Q' || cmd == 'q' {
+				args, err := next(4)
+				if err != nil {
+					return nil, err
+				}
+				ctrl, end = offset(svgPoint{args[0], args[1]}), offset(svgPoint{args[2], args[3]})
+			} else {
+				args, err := next(2)
+				if err != nil {
+					return nil, err
+				}
+				ctrl = pos
+				if strings.IndexByte("QqTt", prevCmd) >= 0 {
+					ctrl = svgPoint{2*pos.x - lastControl.x, 2*pos.y - lastControl.y}
+				}
+				end = offset(svgPoint{args[0], args[1]})
+			}
+			c1 := svgPoint{pos.x + 2.0/3*(ctrl.x-pos.x), pos.y + 2.0/3*(ctrl.y-pos.y)}
+			c2 := svgPoint{end.x + 2.0/3*(ctrl.x-end.x), end.y + 2.0/3*(ctrl.y-end.y)}
+			current = appendCubic(current, pos, c1, c2, end)
+			lastControl, pos = ctrl, end
+		case 'A', 'a':
+			args, err := next(7)
+			if err != nil {
+				return nil, err
+			}
+			end := offset(svgPoint{args[5], args[6]})
+			current = appendSVGArc(current, pos, end, args[0], args[1], args[2], args[3] != 0, args[4] != 0)
+			pos = end
+		}
+		prevCmd = cmd
+	}
+	closePath()
+	return paths, nil
+}
+
+// svgPathTokens splits path data into commands and numbers, handling
+// compact forms such as "M1-2.5.5" and arc flags written without spaces.
+func svgPathTokens(d string) []string {
+	var tokens []string
+	var arcArgs int
+	for idx := 0; idx < len(d); {
+		ch := d[idx]
+		switch {
+		case ch == ' ' || ch == ',' || ch == '\t' || ch == '\n' || ch == '\r':
+			idx++
+		case strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", ch) >= 0:
+			tokens = append(tokens, string(ch))
+			if ch == 'A' || ch == 'a' {
+				arcArgs = 0
+			} else {
+				arcArgs = -1
+			}
+			idx++
+		default:
+			// The large-arc and sweep flags are single digits that may be
+			// followed directly by the next number.
+			if arcArgs >= 0 && (arcArgs%7 == 3 || arcArgs%7 == 4) && (ch == '0' || ch == '1') {
+				tokens = append(tokens, string(ch))
+				arcArgs++
+				idx++
+				continue
+			}
+			end := idx
+			if d[end] == '-' || d[end] == '+' {
+				end++
+			}
+			dot, exp := false, false
+		scan:
+			for ; end < len(d); end++ {
+				c := d[end]
+				switch {
+				case c >= '0' && c <= '9':
+				case c == '.' && !dot && !exp:
+					dot = true
+				case (c == 'e' || c == 'E') && !exp:
+					exp = true
+					if end+1 < len(d) && (d[end+1] == '-' || d[end+1] == '+') {
+						end++
+					}
+				default:
+					break scan
+				}
+			}
+			if end == idx {
+				end++
+			}
+			tokens = append(tokens, d[idx:end])
+			if arcArgs >= 0 {
+				arcArgs++
+			}
+			idx = end
+		}
+	}
+	return tokens
+}
+
+func appendCubic(path []svgPoint, p0, p1, p2, p3 svgPoint) []svgPoint {
+	const steps = 16
+	for step := 1; step <= steps; step++ {
+		t := float64(step) / steps
+		u := 1 - t
+		path = append(path, svgPoint{
+			x: u*u*u*p0.x + 3*u*u*t*p1.x + 3*u*t*t*p2.x + t*t*t*p3.x,
+			y: u*u*u*p0.y + 3*u*u*t*p1.y + 3*u*t*t*p2.y + t*t*t*p3.y,
+		})
+	}
+	return path
+}
+
+// appendSVGArc flattens an elliptical arc using the endpoint to centre
+// conversion from the SVG specification.
+func appendSVGArc(path []svgPoint, from, to svgPoint, rx, ry, rotation float64, large, sweep bool) []svgPoint {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || from == to {
+		return append(path, to)
+	}
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	dx, dy := (from.x-to.x)/2, (from.y-to.y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+
+	if scale := x1*x1/(rx*rx) + y1*y1/(ry*ry); scale > 1 {
+		rx *= math.Sqrt(scale)
+		ry *= math.Sqrt(scale)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+	cx := cos*cx1 - sin*cy1 + (from.x+to.x)/2
+	cy := sin*cx1 + cos*cy1 + (from.y+to.y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	steps := max(4, int(math.Ceil(math.Abs(delta)/(math.Pi/24))))
+	for step := 1; step <= steps; step++ {
+		t := theta + delta*float64(step)/float64(steps)
+		ex, ey := rx*math.Cos(t), ry*math.Sin(t)
+		path = append(path, svgPoint{cos*ex - sin*ey + cx, sin*ex + cos*ey + cy})
+	}
+	return path
+}
+
+// fillPaths blends fill into img wherever the polygons cover a pixel,
+// sampling several scanlines per row and exact horizontal coverage.
+func fillPaths(img *image.NRGBA, paths [][]svgPoint, fill color.NRGBA, evenOdd bool) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	coverage := make([]float64, width*height)
+
+	type crossing struct {
+		x       float64
+		winding int
+	}
+	var crossings []crossing
+	for row := 0; row < height*svgSamples; row++ {
+		y := (float64(row) + 0.5) / svgSamples
+		crossings = crossings[:0]
+		for _, path := range paths {
+			for idx := range path {
+				a, b := path[idx], path[(idx+1)%len(path)]
+				if (a.y <= y) == (b.y <= y) {
+					continue
+				}
+				winding := 1
+				if b.y < a.y {
+					winding = -1
+				}
+				crossings = append(crossings, crossing{x: a.x + (y-a.y)*(b.x-a.x)/(b.y-a.y), winding: winding})
+			}
+		}
+		sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+		line := coverage[(row/svgSamples)*width : (row/svgSamples+1)*width]
+		winding := 0
+		for idx := 0; idx+1 < len(crossings); idx++ {
+			winding += crossings[idx].winding
+			inside := winding != 0
+			if evenOdd {
+				inside = (idx+1)%2 == 1
+			}
+			if inside {
+				addSpan(line, crossings[idx].x, crossings[idx+1].x, 1.0/svgSamples)
+			}
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if cover := clamp01(coverage[y*width+x]); cover > 0 {
+				blend(img, x, y, fill, cover)
+			}
+		}
+	}
+}
+
+func addSpan(line []float64, from, to, weight float64) {
+	from, to = max(0, from), min(float64(len(line)), to)
+	for x := int(from); x < len(line) && float64(x) < to; x++ {
+		left, right := max(from, float64(x)), min(to, float64(x+1))
+		if right > left {
+			line[x] += (right - left) * weight
+		}
+	}
+}
+
+func svgNumbers(raw string) []float64 {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	out := make([]float64, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+func svgLength(raw string) (float64, error) {
+	raw = strings.TrimSuffix(strings.TrimSpace(raw), "px")
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(raw, 64)
+}
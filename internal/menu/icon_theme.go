@@ -0,0 +1,87 @@
+package menu
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+	"github.com/example/gotray/internal/theme"
+)
+
+// themePollInterval controls how often the desktop colour scheme is checked
+// when a dark icon is available.
+const themePollInterval = 10 * time.Second
+
+// trayIcons holds the tray icons for light and dark desktops. A nil icon
+// selects the default icon.
+type trayIcons struct {
+	light []byte
+	dark  []byte
+}
+
+func (i trayIcons) empty() bool {
+	return len(i.light) == 0 && len(i.dark) == 0
+}
+
+// forScheme returns the dark icon on dark desktops when one is set and the
+// light icon otherwise.
+func (i trayIcons) forScheme(scheme theme.Scheme) []byte {
+	if scheme == theme.Dark && len(i.dark) > 0 {
+		return i.dark
+	}
+	return i.light
+}
+
+// localIcons reads the tray icons configured in settings.
+func localIcons(settings config.Settings) trayIcons {
+	return trayIcons{light: readIconFile(settings.Icon), dark: readIconFile(settings.IconDark)}
+}
+
+func readIconFile(path string) []byte {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("tray icon unavailable: %v", err)
+		return nil
+	}
+	return data
+}
+
+// selectIcon picks the icon for the current colour scheme. The scheme is
+// only detected when a dark icon is configured.
+func (r *Runner) selectIcon(icons trayIcons, settings config.Settings) []byte {
+	scheme := theme.Unknown
+	if len(icons.dark) > 0 {
+		scheme = theme.Detect(settings.Theme)
+	}
+	r.mu.Lock()
+	if scheme != r.scheme {
+		logging.Debugf("desktop colour scheme is %q", scheme)
+	}
+	r.scheme = scheme
+	r.mu.Unlock()
+	return icons.forScheme(scheme)
+}
+
+// refreshTheme switches the tray icon when the desktop colour scheme changed
+// since the menu was last published.
+func (r *Runner) refreshTheme() {
+	r.mu.RLock()
+	icons, previous := r.lastIcons, r.scheme
+	r.mu.RUnlock()
+	if len(icons.dark) == 0 {
+		return
+	}
+
+	settings := r.latestSettings()
+	if theme.Detect(settings.Theme) == previous {
+		return
+	}
+	r.setTrayState(r.LatestItems(), r.selectIcon(icons, settings), settings)
+}
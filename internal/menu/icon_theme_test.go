@@ -0,0 +1,50 @@
+package menu
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/theme"
+)
+
+func TestSelectIconFollowsThemeSetting(t *testing.T) {
+	dir := t.TempDir()
+	light := testIcon(t, 16, color.NRGBA{R: 0x20, A: 0xff})
+	dark := testIcon(t, 16, color.NRGBA{R: 0xe0, G: 0xe0, B: 0xe0, A: 0xff})
+	lightPath := filepath.Join(dir, "light.png")
+	darkPath := filepath.Join(dir, "dark.png")
+	if err := os.WriteFile(lightPath, light, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(darkPath, dark, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := config.Settings{Icon: lightPath, IconDark: darkPath, Theme: "dark"}
+	icons := localIcons(settings)
+	r := &Runner{}
+	if got := r.selectIcon(icons, settings); !bytes.Equal(got, dark) {
+		t.Fatalf("expected the dark icon for the dark theme")
+	}
+	if r.scheme != theme.Dark {
+		t.Fatalf("expected the selected scheme to be recorded, got %q", r.scheme)
+	}
+
+	settings.Theme = "light"
+	if got := r.selectIcon(icons, settings); !bytes.Equal(got, light) {
+		t.Fatalf("expected the light icon for the light theme")
+	}
+
+	onlyDark := trayIcons{dark: dark}
+	if got := onlyDark.forScheme(theme.Light); got != nil {
+		t.Fatalf("expected the built-in icon when no light icon is set")
+	}
+
+	if missing := localIcons(config.Settings{Icon: filepath.Join(dir, "missing.png")}); !missing.empty() {
+		t.Fatalf("expected a missing icon file to be ignored")
+	}
+}
@@ -0,0 +1,227 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/example/gotray/internal/config"
+)
+
+// ImportMode selects how imported items are combined with the existing menu.
+type ImportMode string
+
+const (
+	// ImportReplace discards the existing items, or with a parent only the
+	// items below it.
+	ImportReplace ImportMode = "replace"
+	// ImportMerge adds the imported items and resolves duplicate IDs, keeping
+	// the imported order values so both menus interleave.
+	ImportMerge ImportMode = "merge"
+	// ImportAppend adds the imported items below the existing siblings.
+	ImportAppend ImportMode = "append"
+)
+
+// ConflictStrategy resolves an imported item whose ID already exists.
+type ConflictStrategy string
+
+const (
+	// ConflictKeep keeps the existing item and drops the imported one.
+	ConflictKeep ConflictStrategy = "keep"
+	// ConflictTake replaces the existing item with the imported one.
+	ConflictTake ConflictStrategy = "take"
+	// ConflictRenumber adds the imported item under a newly generated ID.
+	ConflictRenumber ConflictStrategy = "renumber"
+)
+
+// ImportOptions configures Import. Conflict defaults to ConflictTake for
+// ImportMerge and ConflictRenumber for ImportAppend and ImportReplace. Parent
+// grafts the top-level imported items below an existing menu or generator
+// item; with ImportReplace they replace the items below it, so an exported
+// submenu can be restored.
+// FreshIDs generates a new ID for every imported item, so a shared subtree
+// can be added several times or below a different parent.
+type ImportOptions struct {
+	Mode     ImportMode
+	Conflict ConflictStrategy
+	Parent   string
+	FreshIDs bool
+}
+
+// ImportResult is the combined menu together with how ID conflicts were
+// resolved.
+type ImportResult struct {
+	Items []config.MenuItem
+	// Kept lists the IDs of existing items that won over an imported item.
+	Kept []string
+	// Renumbered maps imported IDs to the IDs they were added under.
+	Renumbered map[string]string
+}
+
+// Import combines existing and imported items according to opts. Both
+// slices are left untouched; parent references are not validated.
+func Import(existing, imported []config.MenuItem, opts ImportOptions) (ImportResult, error) {
+	incoming := append([]config.MenuItem(nil), imported...)
+	EnsureSequentialOrder(&incoming)
+
+	var items []config.MenuItem
+	switch opts.Mode {
+	case ImportReplace, "":
+		if opts.Parent != "" {
+			items = append(items, existing...)
+			for _, child := range Children(items, opts.Parent) {
+				items, _ = RemoveSubtree(items, child.ID)
+			}
+			EnsureSequentialOrder(&items)
+		} else if !opts.FreshIDs {
+			return ImportResult{Items: incoming}, nil
+		}
+	case ImportMerge, ImportAppend:
+		items = append(items, existing...)
+		EnsureSequentialOrder(&items)
+	default:
+		return ImportResult{}, fmt.Errorf("unknown import mode %q", opts.Mode)
+	}
+
+	conflict := opts.Conflict
+	if conflict == "" {
+		conflict = ConflictTake
+		if opts.Mode != ImportMerge {
+			// Items elsewhere in the menu must not be overwritten or moved
+			// by an imported item with the same ID.
+			conflict = ConflictRenumber
+		}
+	}
+	switch conflict {
+	case ConflictKeep, ConflictTake, ConflictRenumber:
+	default:
+		return ImportResult{}, fmt.Errorf("unknown conflict strategy %q", conflict)
+	}
+
+	if opts.Parent != "" {
+		parent := findItemByID(items, opts.Parent)
+		if parent == nil {
+			return ImportResult{}, fmt.Errorf("parent id %s not found", opts.Parent)
+		}
+		if parent.Type != config.MenuItemMenu && parent.Type != config.MenuItemGenerator {
+			return ImportResult{}, fmt.Errorf("parent %s is a %s item and cannot hold other items", opts.Parent, parent.Type)
+		}
+		for idx := range incoming {
+			if incoming[idx].ParentID == "" {
+				incoming[idx].ParentID = opts.Parent
+			}
+		}
+	}
+
+	// The tray lists higher order values first and both sides are normalised
+	// to steps of 10. Merged items follow existing items with the same order
+	// value; appended items are moved below all existing ones.
+	offset := 1
+	if opts.Mode == ImportAppend {
+		for _, item := range incoming {
+			offset = max(offset, item.Order)
+		}
+	}
+
+	// Unless every item is renumbered, generated IDs must not collide with
+	// imported items that are still to be added.
+	reserved := make(map[string]bool, len(incoming))
+	if !opts.FreshIDs {
+		for _, item := range incoming {
+			reserved[item.ID] = true
+		}
+	}
+
+	result := ImportResult{Renumbered: make(map[string]string)}
+	for _, item := range parentsFirst(incoming) {
+		if renumbered, ok := result.Renumbered[item.ParentID]; ok {
+			item.ParentID = renumbered
+		}
+		item.Order -= offset
+
+		idx := findItemIndex(items, item.ID)
+		if opts.FreshIDs {
+			idx = -1
+			id := generateFreeID(items, reserved, item)
+			result.Renumbered[item.ID] = id
+			item.ID = id
+		}
+		if idx == -1 {
+			items = append(items, item)
+			continue
+		}
+		switch conflict {
+		case ConflictKeep:
+			result.Kept = append(result.Kept, item.ID)
+		case ConflictTake:
+			item.CreatedUTC = items[idx].CreatedUTC
+			items[idx] = item
+		case ConflictRenumber:
+			id := generateFreeID(items, reserved, item)
+			result.Renumbered[item.ID] = id
+			item.ID = id
+			items = append(items, item)
+		}
+	}
+
+	EnsureSequentialOrder(&items)
+	result.Items = items
+	return result, nil
+}
+
+// generateFreeID picks a new ID for item that is neither used in items nor
+// reserved by an imported item that may still be added.
+func generateFreeID(items []config.MenuItem, reserved map[string]bool, item config.MenuItem) string {
+	candidates := append([]config.MenuItem(nil), items...)
+	for {
+		id := GenerateID(candidates, item.ParentID, item.Type)
+		if !reserved[id] {
+			return id
+		}
+		candidates = append(candidates, config.MenuItem{ID: id, ParentID: item.ParentID})
+	}
+}
+
+// parentsFirst orders items so every item follows its parent when the
+// parent is part of items, with siblings in the order the tray shows them,
+// so renumbered IDs ascend down the menu.
+func parentsFirst(items []config.MenuItem) []config.MenuItem {
+	present := make(map[string]bool, len(items))
+	for _, item := range items {
+		present[item.ID] = true
+	}
+	grouped := groupByParent(items)
+
+	ordered := make([]config.MenuItem, 0, len(items))
+	visited := make(map[string]bool, len(items))
+	var visit func(item config.MenuItem)
+	visit = func(item config.MenuItem) {
+		if visited[item.ID] {
+			return
+		}
+		visited[item.ID] = true
+		ordered = append(ordered, item)
+		for _, child := range grouped[item.ID] {
+			visit(child)
+		}
+	}
+	for _, item := range items {
+		if !present[item.ParentID] {
+			for _, sibling := range grouped[item.ParentID] {
+				visit(sibling)
+			}
+		}
+	}
+	// Items in a parent cycle are never reached from a root.
+	for _, item := range items {
+		visit(item)
+	}
+	return ordered
+}
+
+func findItemIndex(items []config.MenuItem, id string) int {
+	for idx := range items {
+		if items[idx].ID == id {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,134 @@
+package menu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func importFixtures() (existing, imported []config.MenuItem) {
+	existing = []config.MenuItem{
+		{ID: "10", Order: 20, Type: config.MenuItemText, Label: "Hello", CreatedUTC: "2024-01-01T00:00:00Z"},
+		{ID: "20", Order: 10, Type: config.MenuItemGenerator, Label: "Hosts", Command: "hosts"},
+	}
+	imported = []config.MenuItem{
+		{ID: "10.1", Order: 10, ParentID: "10", Type: config.MenuItemCommand, Label: "Child", Command: "child"},
+		{ID: "10", Order: 20, Type: config.MenuItemMenu, Label: "Tools", CreatedUTC: "2025-01-01T00:00:00Z"},
+		{ID: "30", Order: 10, Type: config.MenuItemURL, Label: "Docs", URL: "https://example.com"},
+	}
+	return existing, imported
+}
+
+func menuIDs(items []config.MenuItem, parentID string) string {
+	var ids []string
+	for _, item := range Children(items, parentID) {
+		ids = append(ids, item.ID)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestImportConflictStrategies(t *testing.T) {
+	existing, imported := importFixtures()
+
+	kept, err := Import(existing, imported, ImportOptions{Mode: ImportMerge, Conflict: ConflictKeep})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if got := menuIDs(kept.Items, ""); got != "10 20 30" {
+		t.Fatalf("expected interleaved top level, got %q", got)
+	}
+	if item := findItemByID(kept.Items, "10"); item.Label != "Hello" || len(kept.Kept) != 1 {
+		t.Fatalf("expected the existing item to be kept, got %+v (kept %v)", item, kept.Kept)
+	}
+
+	taken, err := Import(existing, imported, ImportOptions{Mode: ImportMerge})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if item := findItemByID(taken.Items, "10"); item.Label != "Tools" || item.CreatedUTC != "2024-01-01T00:00:00Z" {
+		t.Fatalf("expected the imported item with the original creation time, got %+v", item)
+	}
+	if len(taken.Items) != 4 {
+		t.Fatalf("expected 4 items, got %d", len(taken.Items))
+	}
+
+	renumbered, err := Import(existing, imported, ImportOptions{Mode: ImportAppend})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if renumbered.Renumbered["10"] != "40" {
+		t.Fatalf("expected 10 to be renumbered to 40, got %v", renumbered.Renumbered)
+	}
+	if got := menuIDs(renumbered.Items, ""); got != "10 20 40 30" {
+		t.Fatalf("expected imported items below the existing ones, got %q", got)
+	}
+	if got := menuIDs(renumbered.Items, "40"); got != "10.1" {
+		t.Fatalf("expected the child to follow its renumbered parent, got %q", got)
+	}
+	if len(existing) != 2 || existing[0].Order != 20 {
+		t.Fatalf("existing items were modified: %+v", existing)
+	}
+}
+
+func TestImportGraftsBelowParent(t *testing.T) {
+	existing, imported := importFixtures()
+
+	result, err := Import(existing, imported, ImportOptions{Mode: ImportAppend, Parent: "20"})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if got := menuIDs(result.Items, "20"); got != "20.10 30" {
+		t.Fatalf("expected the imported top level below 20, got %q", got)
+	}
+	if got := menuIDs(result.Items, "20.10"); got != "10.1" {
+		t.Fatalf("expected the child below the renumbered menu, got %q", got)
+	}
+
+	if _, err := Import(existing, imported, ImportOptions{Mode: ImportMerge, Parent: "10"}); err == nil {
+		t.Fatalf("expected a text item to be rejected as parent")
+	}
+
+	existing = append(existing, config.MenuItem{ID: "20.1", Order: 10, ParentID: "20", Type: config.MenuItemText, Label: "Old"})
+	result, err = Import(existing, imported, ImportOptions{Mode: ImportReplace, Parent: "20"})
+	if err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if got := menuIDs(result.Items, "20"); got != "20.10 30" {
+		t.Fatalf("expected the imported items to replace the entries below 20, got %q", got)
+	}
+	if got := menuIDs(result.Items, ""); got != "10 20" || result.Items[0].Label != "Hello" {
+		t.Fatalf("expected the items outside 20 to stay, got %q", got)
+	}
+}
+
+func TestImportFreshIDsReRootsSubtree(t *testing.T) {
+	existing := []config.MenuItem{
+		{ID: "10", Order: 10, Type: config.MenuItemGenerator, Label: "Hosts", Command: "hosts"},
+		{ID: "10.1", Order: 10, ParentID: "10", Type: config.MenuItemCommand, Label: "Ping", Command: "ping"},
+	}
+	subtree := Subtree([]config.MenuItem{
+		{ID: "40.2", Order: 10, ParentID: "40.10", Type: config.MenuItemURL, Label: "Docs", URL: "https://example.com"},
+		{ID: "40.10", Order: 10, ParentID: "40", Type: config.MenuItemMenu, Label: "Dev tools"},
+		{ID: "40.1", Order: 20, ParentID: "40.10", Type: config.MenuItemCommand, Label: "Build", Command: "make"},
+		{ID: "40", Order: 20, Type: config.MenuItemGenerator, Label: "Other", Command: "other"},
+	}, "40.10")
+	if len(subtree) != 3 || subtree[0].ID != "40.10" {
+		t.Fatalf("expected the menu and both children, got %+v", subtree)
+	}
+	subtree[0].ParentID = ""
+
+	result, err := Import(existing, subtree, ImportOptions{Mode: ImportAppend, Parent: "10", FreshIDs: true})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if got := menuIDs(result.Items, "10"); got != "10.1 10.10" {
+		t.Fatalf("expected the menu below the existing child, got %q", got)
+	}
+	if got := menuIDs(result.Items, "10.10"); got != "10.10.1 10.10.2" {
+		t.Fatalf("expected renumbered children in tray order, got %q", got)
+	}
+	if result.Renumbered["40.2"] != "10.10.2" || len(result.Renumbered) != 3 {
+		t.Fatalf("unexpected renumbering %v", result.Renumbered)
+	}
+}
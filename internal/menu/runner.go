@@ -10,35 +10,85 @@ import (
 	"time"
 
 	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/locale"
 	"github.com/example/gotray/internal/logging"
+	"github.com/example/gotray/internal/theme"
 	"github.com/example/gotray/internal/trmm"
 )
 
-const defaultRefreshInterval = 30 * time.Second
+const (
+	defaultRefreshInterval = 30 * time.Second
+	directoryPollInterval  = 5 * time.Second
+	// syncingIndicatorDelay keeps quick syncs from flashing the syncing icon.
+	syncingIndicatorDelay = 750 * time.Millisecond
+)
 
 // Runner handles communication with the system service and synchronises menu
 // state for user-session tray processes.
 type trayController interface {
 	Run(ctx context.Context, updates <-chan UpdatePayload) error
+	// SetStatus replaces the tray tooltip, title, and icon state.
+	SetStatus(status TrayStatus)
+	// SetBadge replaces the badge drawn over the tray icon.
+	SetBadge(badge Badge)
+}
+
+// TrayStatus describes the sync health presented by the tray.
+type TrayStatus struct {
+	Tooltip string
+	Title   string
+	State   IconState
 }
 
-// UpdatePayload encapsulates tray menu updates and icon data.
+// UpdatePayload encapsulates tray menu updates, icon data, and tray-wide
+// settings.
 type UpdatePayload struct {
-	Items []config.MenuItem
-	Icon  []byte
+	Items    []config.MenuItem
+	Icon     []byte
+	Settings config.Settings
 }
 
 type Runner struct {
 	refreshInterval time.Duration
 	offline         bool
 
-	mu             sync.RWMutex
-	lastItems      []config.MenuItem
-	lastDigest     string
-	lastIconDigest string
-	lastIcon       []byte
+	mu                 sync.RWMutex
+	lastItems          []config.MenuItem
+	lastDigest         string
+	lastIconDigest     string
+	lastIcon           []byte
+	lastSettings       config.Settings
+	lastSettingsDigest string
+	// lastBaseItems holds the synced items before generator expansion.
+	lastBaseItems []config.MenuItem
+	// lastIcons holds both theme variants of the tray icon; scheme is the
+	// colour scheme used to choose between them.
+	lastIcons trayIcons
+	scheme    theme.Scheme
+	// remoteStatus caches the Tactical RMM tooltip, title, and agent name so
+	// they survive a failed sync.
+	remoteStatus trmm.TrayData
+	// badge is the last provider result; badgeRun and badgeKey record when
+	// and for which configuration the provider was polled.
+	badge    Badge
+	badgeRun time.Time
+	badgeKey string
+
+	// statusMu serialises tray status updates. status is the last state
+	// reported after a sync and syncing is set while a sync is in progress.
+	statusMu sync.Mutex
+	status   TrayStatus
+	syncing  bool
+
+	genMu      sync.Mutex
+	generators map[string]*generatorState
+	// generatorResults delivers background generator runs to the loop.
+	generatorResults chan generatorResult
+	// dirSignature fingerprints the script directories shown in the menu.
+	dirSignature string
 
 	tray            trayController
+	supervisor      *supervisor
 	updates         chan UpdatePayload
 	refreshRequests chan struct{}
 }
@@ -48,11 +98,13 @@ type Runner struct {
 // configuration is used exclusively.
 func NewRunner(offline bool) *Runner {
 	r := &Runner{
-		refreshInterval: defaultRefreshInterval,
-		offline:         offline,
-		refreshRequests: make(chan struct{}, 1),
+		refreshInterval:  defaultRefreshInterval,
+		offline:          offline,
+		refreshRequests:  make(chan struct{}, 1),
+		generatorResults: make(chan generatorResult),
+		supervisor:       newSupervisor(),
 	}
-	r.tray = newTrayController(r.requestRefresh)
+	r.tray = newTrayController(r.requestRefresh, r.supervisor)
 	r.updates = make(chan UpdatePayload, 1)
 	return r
 }
@@ -66,6 +118,7 @@ func (r *Runner) Start(ctx context.Context) error {
 		log.Printf("GoTray running in standalone mode")
 	}
 	logging.Debugf("tray runner initialising with refresh interval %s", r.refreshInterval)
+	defer r.supervisor.Shutdown()
 
 	var trayErr <-chan error
 	if r.tray != nil {
@@ -83,30 +136,58 @@ func (r *Runner) Start(ctx context.Context) error {
 
 	// Perform an initial sync before entering the refresh loop.
 	logging.Debugf("performing initial configuration sync")
-	if err := r.syncOnce(ctx); err != nil {
+	if err := r.syncWithIndicator(ctx); err != nil {
 		log.Printf("initial sync failed: %v", err)
 	}
 	if len(r.LatestItems()) == 0 {
-		r.publish(nil, nil)
+		r.publish(nil, nil, r.latestSettings())
 	} else {
 		log.Printf("GoTray loaded %d menu items", len(r.LatestItems()))
 	}
 
 	ticker := time.NewTicker(r.refreshInterval)
 	defer ticker.Stop()
+	dirPoll := time.NewTicker(directoryPollInterval)
+	defer dirPoll.Stop()
+	themePoll := time.NewTicker(themePollInterval)
+	defer themePoll.Stop()
 
 	for {
+		var generatorDue <-chan time.Time
+		if wait := r.nextGeneratorRun(r.latestBaseItems(), time.Now()); wait > 0 {
+			generatorDue = time.After(wait)
+		}
+		var badgeDue <-chan time.Time
+		if wait := r.nextBadgeRun(time.Now()); wait > 0 {
+			badgeDue = time.After(wait)
+		}
+
 		select {
 		case <-ctx.Done():
 			log.Println("GoTray tray agent stopping")
 			return ctx.Err()
+		case <-generatorDue:
+			r.refreshDynamic(ctx)
+		case result := <-r.generatorResults:
+			if r.applyGeneratorResult(result) {
+				r.rebuildMenu(ctx)
+			}
+		case <-badgeDue:
+			r.refreshBadge(ctx)
+		case <-themePoll.C:
+			r.refreshTheme()
+		case <-dirPoll.C:
+			if r.directoriesChanged() {
+				logging.Debugf("script directory changed; rebuilding menu")
+				r.refreshDynamic(ctx)
+			}
 		case <-ticker.C:
-			if err := r.syncOnce(ctx); err != nil {
+			if err := r.syncWithIndicator(ctx); err != nil {
 				log.Printf("tray refresh failed: %v", err)
 			}
 		case <-r.refreshRequests:
 			logging.Debugf("manual refresh requested")
-			if err := r.syncOnce(ctx); err != nil {
+			if err := r.syncWithIndicator(ctx); err != nil {
 				log.Printf("manual tray refresh failed: %v", err)
 			}
 		case err := <-trayErr:
@@ -125,9 +206,45 @@ func (r *Runner) LatestItems() []config.MenuItem {
 	return out
 }
 
+// syncWithIndicator runs syncOnce and switches the tray icon to the syncing
+// state when the sync takes longer than syncingIndicatorDelay.
+func (r *Runner) syncWithIndicator(ctx context.Context) error {
+	r.statusMu.Lock()
+	r.syncing = true
+	r.statusMu.Unlock()
+
+	timer := time.AfterFunc(syncingIndicatorDelay, r.showSyncing)
+	defer timer.Stop()
+	err := r.syncOnce(ctx)
+
+	// syncOnce reports its outcome through updateStatus; restore the previous
+	// state if it returned before doing so.
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	if r.syncing {
+		r.syncing = false
+		if r.tray != nil {
+			r.tray.SetStatus(r.status)
+		}
+	}
+	return err
+}
+
+func (r *Runner) showSyncing() {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	if !r.syncing || r.tray == nil {
+		return
+	}
+	status := r.status
+	status.State = IconSyncing
+	r.tray.SetStatus(status)
+}
+
 func (r *Runner) syncOnce(ctx context.Context) error {
 	cfg, err := config.Load()
 	if err != nil {
+		r.updateStatus(r.latestSettings(), nil, IconError, err, len(r.latestBaseItems()))
 		return err
 	}
 	logging.Debugf("loaded %d menu items from configuration", len(cfg.Items))
@@ -151,8 +268,10 @@ func (r *Runner) syncOnce(ctx context.Context) error {
 		}
 	}
 
-	cachedItems := r.LatestItems()
-	cachedIcon := r.latestIcon()
+	cachedItems := r.latestBaseItems()
+	r.mu.RLock()
+	cachedIcons := r.lastIcons
+	r.mu.RUnlock()
 
 	items := make([]config.MenuItem, len(cfg.Items))
 	copy(items, cfg.Items)
@@ -188,28 +307,49 @@ func (r *Runner) syncOnce(ctx context.Context) error {
 		logging.Debugf("retaining %d cached Tactical RMM menu items after error", len(items))
 	}
 
-	var icon []byte
-	if trayData != nil && len(trayData.Icon) > 0 {
-		icon = trayData.Icon
-		logging.Debugf("using Tactical RMM provided icon (%d bytes)", len(icon))
-	} else if trayErr != nil && len(icon) == 0 && len(cachedIcon) > 0 {
-		icon = cachedIcon
+	var icons trayIcons
+	if trayData != nil && (len(trayData.Icon) > 0 || len(trayData.IconDark) > 0) {
+		icons = trayIcons{light: trayData.Icon, dark: trayData.IconDark}
+		logging.Debugf("using Tactical RMM provided icon (%d bytes, dark variant %d bytes)", len(icons.light), len(icons.dark))
+	} else if trayErr != nil && !cachedIcons.empty() {
+		icons = cachedIcons
 		logging.Debugf("retaining cached Tactical RMM icon after error")
+	} else {
+		icons = localIcons(cfg.Settings)
 	}
 
-	r.setTrayState(items, icon)
+	r.mu.Lock()
+	r.lastBaseItems = make([]config.MenuItem, len(items))
+	copy(r.lastBaseItems, items)
+	r.lastIcons = icons
+	r.mu.Unlock()
+
+	r.setTrayState(r.expandItems(ctx, items, nil), r.selectIcon(icons, cfg.Settings), cfg.Settings)
+	state := IconNormal
+	switch {
+	case r.offline:
+		state = IconOffline
+	case fallbackToCached || (trayErr != nil && trayData != nil):
+		// Either the cached menu is shown or Tactical RMM returned only
+		// part of the tray data.
+		state = IconWarning
+	case trayErr != nil:
+		state = IconError
+	}
+	r.updateStatus(cfg.Settings, trayData, state, trayErr, len(items))
 	if seeded {
 		log.Printf("GoTray created a fresh configuration with %d default items", len(items))
 	}
 	return trayErr
 }
 
-func (r *Runner) setTrayState(items []config.MenuItem, icon []byte) {
+func (r *Runner) setTrayState(items []config.MenuItem, icon []byte, settings config.Settings) {
 	digest := hashItems(items)
 	iconDigest := hashBytes(icon)
+	settingsDigest := hashSettings(settings)
 
 	r.mu.Lock()
-	if digest != "" && digest == r.lastDigest && iconDigest == r.lastIconDigest {
+	if digest != "" && digest == r.lastDigest && iconDigest == r.lastIconDigest && settingsDigest == r.lastSettingsDigest {
 		r.mu.Unlock()
 		return
 	}
@@ -218,9 +358,60 @@ func (r *Runner) setTrayState(items []config.MenuItem, icon []byte) {
 	r.lastDigest = digest
 	r.lastIconDigest = iconDigest
 	r.lastIcon = cloneIcon(icon)
+	r.lastSettings = settings
+	r.lastSettingsDigest = settingsDigest
 	r.mu.Unlock()
 	logging.Debugf("published tray state with %d items (digest=%s iconDigest=%s)", len(items), digest, iconDigest)
-	r.publish(items, icon)
+	r.publish(items, icon, settings)
+}
+
+// refreshDynamic rescans script directories and re-evaluates generators whose
+// interval has elapsed without reloading the configuration or contacting
+// Tactical RMM.
+func (r *Runner) refreshDynamic(ctx context.Context) {
+	items := r.latestBaseItems()
+	expanded := r.expandItems(ctx, items, r.generatorDue(time.Now()))
+	r.setTrayState(expanded, r.latestIcon(), r.latestSettings())
+}
+
+// rebuildMenu publishes the menu again with the latest generator output,
+// without starting further generator runs.
+func (r *Runner) rebuildMenu(ctx context.Context) {
+	items := r.latestBaseItems()
+	expanded := r.expandItems(ctx, items, func(config.MenuItem) bool { return false })
+	r.setTrayState(expanded, r.latestIcon(), r.latestSettings())
+}
+
+// expandItems adds the entries contributed by script directories and
+// generators to the configured items.
+func (r *Runner) expandItems(ctx context.Context, items []config.MenuItem, due func(config.MenuItem) bool) []config.MenuItem {
+	signature := directorySignature(items)
+	r.mu.Lock()
+	r.dirSignature = signature
+	r.mu.Unlock()
+	return r.expandGenerators(ctx, expandDirectories(items), due)
+}
+
+// directoriesChanged reports whether any script directory differs from the
+// last time the menu was built.
+func (r *Runner) directoriesChanged() bool {
+	items := r.latestBaseItems()
+	if !hasDirectories(items) {
+		return false
+	}
+	signature := directorySignature(items)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return signature != r.dirSignature
+}
+
+func (r *Runner) latestBaseItems() []config.MenuItem {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]config.MenuItem, len(r.lastBaseItems))
+	copy(out, r.lastBaseItems)
+	return out
 }
 
 func (r *Runner) latestIcon() []byte {
@@ -229,6 +420,12 @@ func (r *Runner) latestIcon() []byte {
 	return cloneIcon(r.lastIcon)
 }
 
+func (r *Runner) latestSettings() config.Settings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastSettings
+}
+
 func (r *Runner) requestRefresh() {
 	if r.refreshRequests == nil {
 		return
@@ -239,7 +436,7 @@ func (r *Runner) requestRefresh() {
 	}
 }
 
-func (r *Runner) publish(items []config.MenuItem, icon []byte) {
+func (r *Runner) publish(items []config.MenuItem, icon []byte, settings config.Settings) {
 	if r.updates == nil {
 		return
 	}
@@ -248,12 +445,12 @@ func (r *Runner) publish(items []config.MenuItem, icon []byte) {
 		items = DefaultItems()
 	}
 
-	payload := make([]config.MenuItem, len(items))
-	copy(payload, items)
+	payload := localizeItems(items, locale.Detect(settings.Locale))
 
 	update := UpdatePayload{
-		Items: payload,
-		Icon:  cloneIcon(icon),
+		Items:    payload,
+		Icon:     cloneIcon(icon),
+		Settings: settings,
 	}
 
 	select {
@@ -284,6 +481,15 @@ func hashItems(items []config.MenuItem) string {
 	return hex.EncodeToString(sum[:])
 }
 
+func hashSettings(settings config.Settings) string {
+	payload, err := json.Marshal(settings)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
 func hashBytes(icon []byte) string {
 	normalized := normalizedIcon(icon)
 	if len(normalized) == 0 {
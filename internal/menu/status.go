@@ -0,0 +1,143 @@
+package menu
+
+import (
+	"bytes"
+	"os"
+	"os/user"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+	"github.com/example/gotray/internal/trmm"
+)
+
+const (
+	defaultTooltip  = "GoTray"
+	maxTooltipError = 96
+)
+
+// statusData is exposed to the tray tooltip and title templates.
+type statusData struct {
+	// Status is "ok" after a successful sync, "warning" when cached items are
+	// shown or Tactical RMM returned partial data, "error" when the sync
+	// failed otherwise, and "offline" when synchronisation is disabled.
+	Status       string
+	Error        string
+	LastSync     string
+	LastSyncTime time.Time
+	AgentName    string
+	Hostname     string
+	Username     string
+	Items        int
+}
+
+func newStatusData(now time.Time) statusData {
+	data := statusData{
+		Status:       "ok",
+		LastSync:     now.Format("15:04"),
+		LastSyncTime: now,
+	}
+	if host, err := os.Hostname(); err == nil {
+		data.Hostname = host
+	}
+	if current, err := user.Current(); err == nil {
+		data.Username = current.Username
+	}
+	return data
+}
+
+// renderStatus expands a tooltip or title template, returning fallback when
+// the template is empty or fails to render.
+func renderStatus(text string, data statusData, fallback string) string {
+	if strings.TrimSpace(text) == "" {
+		return fallback
+	}
+	rendered, err := executeStatus(text, data)
+	if err != nil {
+		logging.Debugf("failed to render tray status template %q: %v", text, err)
+		return fallback
+	}
+	return rendered
+}
+
+// ValidateStatusTemplate reports whether text is a valid tooltip or title
+// template, including references to unknown fields.
+func ValidateStatusTemplate(text string) error {
+	_, err := executeStatus(text, newStatusData(time.Now()))
+	return err
+}
+
+func executeStatus(text string, data statusData) (string, error) {
+	tmpl, err := template.New("status").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// updateStatus renders the tooltip and title after a sync and reports the
+// icon state to the tray. Tactical RMM templates take precedence over the
+// local settings.
+func (r *Runner) updateStatus(settings config.Settings, data *trmm.TrayData, state IconState, syncErr error, items int) {
+	status := newStatusData(time.Now())
+	status.Items = items
+
+	r.mu.Lock()
+	switch {
+	case data != nil:
+		r.remoteStatus = trmm.TrayData{Tooltip: data.Tooltip, Title: data.Title, AgentName: data.AgentName}
+	case syncErr == nil:
+		r.remoteStatus = trmm.TrayData{}
+	}
+	remote := r.remoteStatus
+	r.mu.Unlock()
+
+	tooltip, title := settings.Tooltip, settings.Title
+	if remote.Tooltip != "" {
+		tooltip = remote.Tooltip
+	}
+	if remote.Title != "" {
+		title = remote.Title
+	}
+	status.AgentName = remote.AgentName
+
+	if state != IconNormal {
+		status.Status = string(state)
+	}
+	if syncErr != nil {
+		status.Error = syncErr.Error()
+	}
+
+	tray := TrayStatus{
+		Tooltip: renderStatus(tooltip, status, defaultTooltip),
+		Title:   renderStatus(title, status, ""),
+		State:   state,
+	}
+	if status.Error != "" && !strings.Contains(tray.Tooltip, status.Error) {
+		tray.Tooltip += "\nSync failed: " + truncateStatus(status.Error, maxTooltipError)
+	}
+
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	r.status = tray
+	r.syncing = false
+	if r.tray != nil {
+		r.tray.SetStatus(tray)
+	}
+}
+
+// truncateStatus shortens text to limit runes; Windows truncates tooltips
+// longer than 127 characters.
+func truncateStatus(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,106 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/trmm"
+)
+
+type statusRecorder struct {
+	tooltip, title string
+	state          IconState
+	badge          Badge
+}
+
+func (s *statusRecorder) Run(context.Context, <-chan UpdatePayload) error { return nil }
+
+func (s *statusRecorder) SetBadge(badge Badge) {
+	s.badge = badge
+}
+
+func (s *statusRecorder) SetStatus(status TrayStatus) {
+	s.tooltip, s.title, s.state = status.Tooltip, status.Title, status.State
+}
+
+func TestRenderStatus(t *testing.T) {
+	data := statusData{Status: "ok", LastSync: "09:30", AgentName: "LAB-PC-04", Items: 3}
+
+	if got := renderStatus("{{.AgentName}} – synced {{.LastSync}}", data, defaultTooltip); got != "LAB-PC-04 – synced 09:30" {
+		t.Fatalf("unexpected tooltip %q", got)
+	}
+	if got := renderStatus("", data, defaultTooltip); got != defaultTooltip {
+		t.Fatalf("expected fallback for an empty template, got %q", got)
+	}
+	if got := renderStatus("{{.Missing}}", data, defaultTooltip); got != defaultTooltip {
+		t.Fatalf("expected fallback for an invalid template, got %q", got)
+	}
+	if err := ValidateStatusTemplate("{{if eq .Status \"error\"}}Sync failed{{end}}"); err != nil {
+		t.Fatalf("expected a valid template, got %v", err)
+	}
+	if err := ValidateStatusTemplate("{{.Agent}}"); err == nil {
+		t.Fatalf("expected unknown fields to be rejected")
+	}
+}
+
+func TestUpdateStatusPrefersTacticalRMMAndSurvivesErrors(t *testing.T) {
+	tray := &statusRecorder{}
+	r := &Runner{tray: tray}
+	settings := config.Settings{Tooltip: "Local {{.Status}}", Title: "{{.Items}}"}
+
+	r.updateStatus(settings, nil, IconNormal, nil, 4)
+	if tray.tooltip != "Local ok" || tray.title != "4" || tray.state != IconNormal {
+		t.Fatalf("unexpected local status %q / %q", tray.tooltip, tray.title)
+	}
+
+	r.updateStatus(settings, &trmm.TrayData{Tooltip: "{{.AgentName}}: {{.Status}}", AgentName: "LAB-PC-04"}, IconNormal, nil, 4)
+	if tray.tooltip != "LAB-PC-04: ok" {
+		t.Fatalf("expected the Tactical RMM tooltip, got %q", tray.tooltip)
+	}
+
+	r.updateStatus(settings, nil, IconError, errors.New("timeout"), 4)
+	if tray.tooltip != "LAB-PC-04: error\nSync failed: timeout" || tray.state != IconError {
+		t.Fatalf("expected the cached tooltip and the error after a failed sync, got %q (%s)", tray.tooltip, tray.state)
+	}
+}
+
+func TestUpdateStatusKeepsErrorsShownByTemplate(t *testing.T) {
+	tray := &statusRecorder{}
+	r := &Runner{tray: tray}
+	settings := config.Settings{Tooltip: "{{if .Error}}Stale menu: {{.Error}}{{end}}"}
+
+	r.updateStatus(settings, nil, IconWarning, errors.New("connection refused"), 2)
+	if tray.tooltip != "Stale menu: connection refused" || tray.state != IconWarning {
+		t.Fatalf("unexpected status %q (%s)", tray.tooltip, tray.state)
+	}
+}
+
+func TestSyncingIndicatorRestoresPreviousStatus(t *testing.T) {
+	tray := &statusRecorder{}
+	r := &Runner{tray: tray}
+	r.updateStatus(config.Settings{}, nil, IconWarning, errors.New("timeout"), 1)
+
+	r.statusMu.Lock()
+	r.syncing = true
+	r.statusMu.Unlock()
+	r.showSyncing()
+	if tray.state != IconSyncing || tray.tooltip != defaultTooltip+"\nSync failed: timeout" {
+		t.Fatalf("expected the syncing state with the previous tooltip, got %q (%s)", tray.tooltip, tray.state)
+	}
+
+	r.updateStatus(config.Settings{}, nil, IconNormal, nil, 1)
+	r.showSyncing()
+	if tray.state != IconNormal {
+		t.Fatalf("expected a finished sync to ignore the syncing timer, got %s", tray.state)
+	}
+}
+
+func TestNewStatusDataFormatsLastSync(t *testing.T) {
+	now := time.Date(2025, 3, 4, 17, 5, 0, 0, time.Local)
+	if got := newStatusData(now).LastSync; got != "17:05" {
+		t.Fatalf("unexpected LastSync %q", got)
+	}
+}
@@ -0,0 +1,366 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+	"github.com/example/gotray/internal/runlog"
+)
+
+// stopGracePeriod is how long a task may take to exit after a polite stop
+// request before it is killed outright.
+const stopGracePeriod = 5 * time.Second
+
+var (
+	errAlreadyRunning = errors.New("command is already running")
+	errShuttingDown   = errors.New("tray is shutting down")
+)
+
+// TaskInfo describes a command currently tracked by the supervisor.
+type TaskInfo struct {
+	ID      int
+	ItemID  string
+	Label   string
+	PID     int
+	Started time.Time
+}
+
+// supervisor launches command items outside the Runner context, reaps them
+// when they exit, and stops them on timeout, on request, or when the tray
+// quits unless the item asked to be detached.
+type supervisor struct {
+	mu       sync.Mutex
+	nextID   int
+	tasks    map[int]*task
+	closed   bool
+	onChange func()
+}
+
+type task struct {
+	id       int
+	item     config.MenuItem
+	started  time.Time
+	process  *os.Process
+	done     chan struct{}
+	stopOnce sync.Once
+	reason   string
+}
+
+func newSupervisor() *supervisor {
+	return &supervisor{tasks: make(map[int]*task)}
+}
+
+// SetOnChange registers a callback invoked whenever a task starts or exits.
+func (s *supervisor) SetOnChange(fn func()) {
+	s.mu.Lock()
+	s.onChange = fn
+	s.mu.Unlock()
+}
+
+// Run launches the command or workflow item and blocks until it exits,
+// returning the recorded outcome.
+func (s *supervisor) Run(item config.MenuItem) (runlog.Record, error) {
+	started := time.Now()
+	rec := runlog.Record{
+		ID:         runlog.NewID(started),
+		ItemID:     item.ID,
+		Label:      item.Label,
+		Command:    item.Command,
+		Arguments:  append([]string(nil), item.Arguments...),
+		WorkingDir: item.WorkingDir,
+		StartedUTC: started.UTC().Format(time.RFC3339),
+	}
+
+	steps := itemSteps(item)
+	if len(steps) == 0 {
+		return rec, errors.New("command is empty")
+	}
+
+	output, err := runlog.CreateOutput(rec)
+	if err != nil {
+		return rec, err
+	}
+
+	t, err := s.register(item, started)
+	if err != nil {
+		output.Close()
+		return rec, err
+	}
+
+	if item.TimeoutSeconds > 0 {
+		timer := time.AfterFunc(time.Duration(item.TimeoutSeconds)*time.Second, func() {
+			s.stopTask(t, fmt.Sprintf("timed out after %ds", item.TimeoutSeconds))
+		})
+		defer timer.Stop()
+	}
+
+	workflow := item.Type == config.MenuItemWorkflow
+	aborted := false
+	for idx, step := range steps {
+		result := runlog.StepResult{Name: step.Name, Command: step.Command, Arguments: append([]string(nil), step.Arguments...)}
+		if aborted || s.stopReason(t) != "" {
+			result.Skipped = true
+			result.ExitCode = -1
+			rec.Steps = append(rec.Steps, result)
+			continue
+		}
+
+		if workflow {
+			fmt.Fprintf(output, "==> [%d/%d] %s\n", idx+1, len(steps), describeStep(step))
+		}
+		stepStarted := time.Now()
+		result.ExitCode, result.Error = s.runStep(t, item, step, output)
+		result.DurationMS = time.Since(stepStarted).Milliseconds()
+		if workflow {
+			fmt.Fprintf(output, "<== step %d exited with status %d after %s\n", idx+1, result.ExitCode, time.Duration(result.DurationMS)*time.Millisecond)
+		}
+		logging.Debugf("item %s step %d/%d finished with exit code %d", item.ID, idx+1, len(steps), result.ExitCode)
+
+		rec.Steps = append(rec.Steps, result)
+		if result.ExitCode != 0 || result.Error != "" {
+			rec.ExitCode, rec.Error = result.ExitCode, result.Error
+			aborted = !step.ContinueOnError
+		}
+	}
+	output.Close()
+	rec.Truncated = output.Truncated()
+
+	finished := time.Now()
+	rec.FinishedUTC = finished.UTC().Format(time.RFC3339)
+	rec.DurationMS = finished.Sub(started).Milliseconds()
+	if reason := s.stopReason(t); reason != "" {
+		rec.Error = reason
+	}
+	if !workflow {
+		rec.Steps = nil
+	}
+
+	logging.Debugf("item %s finished with exit code %d after %s", item.ID, rec.ExitCode, rec.Duration())
+	if err := runlog.Finish(rec); err != nil {
+		log.Printf("failed to record command output for item %s: %v", item.ID, err)
+	}
+
+	s.unregister(t)
+	s.notify()
+	return rec, nil
+}
+
+// runStep executes a single command for the task, returning its exit code and
+// any launch or termination error.
+func (s *supervisor) runStep(t *task, item config.MenuItem, step config.WorkflowStep, output *runlog.Output) (int, string) {
+	cmd := exec.Command(step.Command, step.Arguments...)
+	if step.WorkingDir != "" {
+		cmd.Dir = step.WorkingDir
+	}
+	if item.Detach {
+		// A pipe would break once GoTray quits, so detached commands write
+		// to the file itself and their output is capped when they exit.
+		cmd.Stdout, cmd.Stderr = output.File(), output.File()
+	} else {
+		cmd.Stdout, cmd.Stderr = output, output
+		// Background children may keep the pipe open; stop copying their
+		// output shortly after the command itself exits.
+		cmd.WaitDelay = time.Second
+	}
+	configureProcess(cmd, item.Detach)
+
+	logging.Debugf("launching item %s: %s %v (detach=%t timeout=%ds)", item.ID, step.Command, step.Arguments, item.Detach, item.TimeoutSeconds)
+	if err := cmd.Start(); err != nil {
+		return exitStatus(err)
+	}
+
+	s.mu.Lock()
+	t.process = cmd.Process
+	stopRequested := t.reason != ""
+	s.mu.Unlock()
+	if stopRequested {
+		s.signal(t, cmd.Process)
+	}
+	s.notify()
+
+	err := cmd.Wait()
+
+	s.mu.Lock()
+	t.process = nil
+	s.mu.Unlock()
+	return exitStatus(err)
+}
+
+// itemSteps normalises command and workflow items into the commands to run.
+func itemSteps(item config.MenuItem) []config.WorkflowStep {
+	if item.Type == config.MenuItemWorkflow {
+		return item.Steps
+	}
+	if item.Command == "" {
+		return nil
+	}
+	return []config.WorkflowStep{{Command: item.Command, Arguments: item.Arguments, WorkingDir: item.WorkingDir}}
+}
+
+func describeStep(step config.WorkflowStep) string {
+	line := strings.TrimSpace(step.Command + " " + strings.Join(step.Arguments, " "))
+	if step.Name != "" {
+		return step.Name + ": " + line
+	}
+	return line
+}
+
+// Running lists the tasks that have not exited yet, oldest first.
+func (s *supervisor) Running() []TaskInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]TaskInfo, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		info := TaskInfo{ID: t.id, ItemID: t.item.ID, Label: t.item.Label, Started: t.started}
+		if t.process != nil {
+			info.PID = t.process.Pid
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Stop terminates a running task by identifier.
+func (s *supervisor) Stop(id int) error {
+	s.mu.Lock()
+	t := s.tasks[id]
+	s.mu.Unlock()
+	if t == nil {
+		return fmt.Errorf("task %d is not running", id)
+	}
+	s.stopTask(t, "stopped by user")
+	return nil
+}
+
+// Shutdown stops every task that is not detached and waits briefly for them
+// to exit. Detached tasks are left running.
+func (s *supervisor) Shutdown() {
+	s.mu.Lock()
+	s.closed = true
+	pending := make([]*task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		if t.item.Detach {
+			logging.Debugf("leaving detached command item %s running", t.item.ID)
+			continue
+		}
+		pending = append(pending, t)
+	}
+	s.mu.Unlock()
+
+	for _, t := range pending {
+		s.stopTask(t, "stopped when GoTray quit")
+	}
+
+	deadline := time.After(stopGracePeriod + time.Second)
+	for _, t := range pending {
+		select {
+		case <-t.done:
+		case <-deadline:
+			return
+		}
+	}
+}
+
+func (s *supervisor) register(item config.MenuItem, started time.Time) (*task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, errShuttingDown
+	}
+	if item.SingleInstance {
+		for _, existing := range s.tasks {
+			if existing.item.ID == item.ID {
+				return nil, errAlreadyRunning
+			}
+		}
+	}
+
+	s.nextID++
+	t := &task{id: s.nextID, item: item, started: started, done: make(chan struct{})}
+	s.tasks[t.id] = t
+	return t, nil
+}
+
+func (s *supervisor) unregister(t *task) {
+	s.mu.Lock()
+	delete(s.tasks, t.id)
+	s.mu.Unlock()
+	close(t.done)
+}
+
+func (s *supervisor) stopTask(t *task, reason string) {
+	t.stopOnce.Do(func() {
+		s.mu.Lock()
+		t.reason = reason
+		process := t.process
+		s.mu.Unlock()
+
+		// Tasks that have not started yet are signalled by Run once the
+		// process exists.
+		if process != nil {
+			s.signal(t, process)
+		}
+	})
+}
+
+func (s *supervisor) signal(t *task, process *os.Process) {
+	logging.Debugf("stopping command item %s (pid %d): %s", t.item.ID, process.Pid, s.stopReason(t))
+	if err := terminateProcess(process); err != nil {
+		logging.Debugf("graceful stop of pid %d failed: %v", process.Pid, err)
+	}
+
+	go func() {
+		select {
+		case <-t.done:
+		case <-time.After(stopGracePeriod):
+			if err := killProcess(process); err != nil {
+				logging.Debugf("kill of pid %d failed: %v", process.Pid, err)
+			}
+		}
+	}()
+}
+
+func (s *supervisor) stopReason(t *task) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return t.reason
+}
+
+func (s *supervisor) notify() {
+	s.mu.Lock()
+	fn := s.onChange
+	s.mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+func exitStatus(err error) (int, string) {
+	if err == nil || errors.Is(err, exec.ErrWaitDelay) {
+		return 0, ""
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			// Terminated by a signal rather than exiting normally.
+			return code, exitErr.Error()
+		}
+		return code, ""
+	}
+	return -1, err.Error()
+}
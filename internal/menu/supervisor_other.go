@@ -0,0 +1,28 @@
+//go:build !windows
+
+package menu
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func configureProcess(cmd *exec.Cmd, detach bool) {
+	// Each command gets its own process group so stopping it also stops any
+	// children it spawned. Detached commands additionally start a new session
+	// so signals aimed at the tray's terminal or session never reach them.
+	if detach {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func terminateProcess(process *os.Process) error {
+	return syscall.Kill(-process.Pid, syscall.SIGTERM)
+}
+
+func killProcess(process *os.Process) error {
+	return syscall.Kill(-process.Pid, syscall.SIGKILL)
+}
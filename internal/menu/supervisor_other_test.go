@@ -0,0 +1,163 @@
+//go:build !windows
+
+package menu
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/runlog"
+)
+
+func TestSupervisorCapturesOutputAndExitCode(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	sup := newSupervisor()
+	rec, err := sup.Run(config.MenuItem{
+		ID:        "10",
+		Label:     "Echo",
+		Command:   "sh",
+		Arguments: []string{"-c", "echo hello; echo oops >&2; exit 3"},
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if rec.ExitCode != 3 {
+		t.Fatalf("expected exit code 3, got %d", rec.ExitCode)
+	}
+
+	output, err := runlog.ReadOutput(rec)
+	if err != nil {
+		t.Fatalf("ReadOutput returned error: %v", err)
+	}
+	if !strings.Contains(string(output), "hello") || !strings.Contains(string(output), "oops") {
+		t.Fatalf("expected stdout and stderr to be captured, got %q", output)
+	}
+	if len(sup.Running()) != 0 {
+		t.Fatalf("expected no running tasks after exit")
+	}
+}
+
+func TestSupervisorDoesNotWaitForBackgroundChildren(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	started := time.Now()
+	rec, err := newSupervisor().Run(config.MenuItem{
+		ID:        "10",
+		Command:   "sh",
+		Arguments: []string{"-c", "sleep 30 & echo launched"},
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Fatalf("expected Run to return once the command exited, took %s", elapsed)
+	}
+	if rec.ExitCode != 0 || rec.Error != "" {
+		t.Fatalf("expected a clean exit, got %+v", rec)
+	}
+	output, err := runlog.ReadOutput(rec)
+	if err != nil || !strings.Contains(string(output), "launched") {
+		t.Fatalf("expected the output to be captured, got %q (%v)", output, err)
+	}
+}
+
+func TestSupervisorEnforcesTimeout(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	sup := newSupervisor()
+	started := time.Now()
+	rec, err := sup.Run(config.MenuItem{
+		ID:             "10",
+		Label:          "Sleep",
+		Command:        "sleep",
+		Arguments:      []string{"30"},
+		TimeoutSeconds: 1,
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Fatalf("timeout was not enforced, ran for %s", elapsed)
+	}
+	if rec.Error != "timed out after 1s" {
+		t.Fatalf("expected timeout reason, got %q", rec.Error)
+	}
+}
+
+func TestSupervisorSingleInstance(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	sup := newSupervisor()
+	item := config.MenuItem{ID: "10", Label: "Sleep", Command: "sleep", Arguments: []string{"30"}, SingleInstance: true}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sup.Run(item)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sup.Running()) == 0 || sup.Running()[0].PID == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("task never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := sup.Run(item); !errors.Is(err, errAlreadyRunning) {
+		t.Fatalf("expected errAlreadyRunning, got %v", err)
+	}
+
+	sup.Shutdown()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("shutdown did not stop the running task")
+	}
+	if _, err := sup.Run(item); !errors.Is(err, errShuttingDown) {
+		t.Fatalf("expected errShuttingDown after shutdown, got %v", err)
+	}
+}
+
+func TestSupervisorRunsWorkflowSteps(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	sup := newSupervisor()
+	rec, err := sup.Run(config.MenuItem{
+		ID:    "10",
+		Type:  config.MenuItemWorkflow,
+		Label: "Restart",
+		Steps: []config.WorkflowStep{
+			{Name: "stop", Command: "sh", Arguments: []string{"-c", "echo stopping; exit 1"}, ContinueOnError: true},
+			{Name: "clear", Command: "sh", Arguments: []string{"-c", "echo clearing; exit 2"}},
+			{Name: "start", Command: "sh", Arguments: []string{"-c", "echo starting"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(rec.Steps) != 3 {
+		t.Fatalf("expected 3 step results, got %d", len(rec.Steps))
+	}
+	if rec.Steps[0].ExitCode != 1 || rec.Steps[1].ExitCode != 2 {
+		t.Fatalf("unexpected step exit codes: %+v", rec.Steps)
+	}
+	if !rec.Steps[2].Skipped {
+		t.Fatalf("expected the step after a failure to be skipped")
+	}
+	if rec.ExitCode != 2 {
+		t.Fatalf("expected aggregated exit code 2, got %d", rec.ExitCode)
+	}
+
+	output, err := runlog.ReadOutput(rec)
+	if err != nil {
+		t.Fatalf("ReadOutput returned error: %v", err)
+	}
+	if !strings.Contains(string(output), "==> [2/3] clear:") || strings.Contains(string(output), "starting") {
+		t.Fatalf("unexpected workflow output %q", output)
+	}
+}
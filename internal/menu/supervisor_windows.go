@@ -0,0 +1,32 @@
+//go:build windows
+
+package menu
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+func configureProcess(cmd *exec.Cmd, detach bool) {
+	flags := uint32(windows.CREATE_NEW_PROCESS_GROUP)
+	if detach {
+		// Allow detached commands to outlive any job object the tray runs in.
+		flags |= windows.CREATE_BREAKAWAY_FROM_JOB
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: flags}
+}
+
+func terminateProcess(process *os.Process) error {
+	return exec.Command("taskkill", "/T", "/PID", strconv.Itoa(process.Pid)).Run()
+}
+
+func killProcess(process *os.Process) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid)).Run(); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package menu
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/example/gotray/internal/config"
+)
+
+// commandPlaceholder marks where the command line is inserted in a terminal
+// template. Templates without it receive the command as trailing arguments.
+const commandPlaceholder = "{command}"
+
+// keepOpenScript waits for the user before the terminal window closes.
+const keepOpenScript = `"$@"; status=$?; printf '\n[process exited with status %s] Press Enter to close...' "$status"; read _`
+
+// linuxTerminals lists fallback emulators in preference order together with
+// the switch each one uses to introduce the command to run.
+var linuxTerminals = []struct {
+	name string
+	flag string
+}{
+	{name: "x-terminal-emulator", flag: "-e"},
+	{name: "gnome-terminal", flag: "--"},
+	{name: "konsole", flag: "-e"},
+	{name: "xterm", flag: "-e"},
+}
+
+type terminalEnv struct {
+	goos     string
+	getenv   func(string) string
+	lookPath func(string) (string, error)
+}
+
+func systemTerminalEnv() terminalEnv {
+	return terminalEnv{goos: runtime.GOOS, getenv: os.Getenv, lookPath: exec.LookPath}
+}
+
+// wrapInTerminal rewrites a command item so it launches inside a terminal
+// window using the configured template or a detected emulator.
+func wrapInTerminal(item config.MenuItem, template string) (config.MenuItem, error) {
+	command, args, err := terminalLaunch(systemTerminalEnv(), template, item.Command, item.Arguments, item.KeepOpen)
+	if err != nil {
+		return item, err
+	}
+	item.Command = command
+	item.Arguments = args
+	return item, nil
+}
+
+func terminalLaunch(env terminalEnv, template, command string, args []string, keepOpen bool) (string, []string, error) {
+	if command == "" {
+		return "", nil, errors.New("command is empty")
+	}
+	argv := append([]string{command}, args...)
+
+	if env.goos == "windows" {
+		return windowsTerminal(template, argv, keepOpen)
+	}
+
+	if keepOpen {
+		argv = append([]string{"sh", "-c", keepOpenScript, "gotray"}, argv...)
+	}
+
+	if template = strings.TrimSpace(template); template != "" {
+		return expandTemplate(template, argv)
+	}
+
+	if env.goos == "darwin" {
+		script := shellJoin(argv)
+		if !keepOpen {
+			script += "; exit"
+		}
+		return "osascript", []string{"-e", `tell application "Terminal" to do script "` + appleScriptEscape(script) + `"`, "-e", `tell application "Terminal" to activate`}, nil
+	}
+
+	if custom := strings.TrimSpace(env.getenv("TERMINAL")); custom != "" {
+		fields := strings.Fields(custom)
+		if _, err := env.lookPath(fields[0]); err == nil {
+			return fields[0], append(append(fields[1:], "-e"), argv...), nil
+		}
+	}
+	for _, candidate := range linuxTerminals {
+		if _, err := env.lookPath(candidate.name); err == nil {
+			return candidate.name, append([]string{candidate.flag}, argv...), nil
+		}
+	}
+	return "", nil, errors.New("no terminal emulator found; set $TERMINAL or configure a terminal template")
+}
+
+func windowsTerminal(template string, argv []string, keepOpen bool) (string, []string, error) {
+	if template = strings.TrimSpace(template); template != "" {
+		return expandTemplate(template, argv)
+	}
+	mode := "/C"
+	if keepOpen {
+		mode = "/K"
+	}
+	// "start" opens a new console window; the empty string is its title.
+	return "cmd.exe", append([]string{"/C", "start", "", "cmd.exe", mode}, argv...), nil
+}
+
+func expandTemplate(template string, argv []string) (string, []string, error) {
+	fields := strings.Fields(template)
+	if len(fields) == 0 {
+		return "", nil, errors.New("terminal template is empty")
+	}
+
+	out := make([]string, 0, len(fields)+len(argv))
+	substituted := false
+	for _, field := range fields {
+		if field == commandPlaceholder {
+			out = append(out, argv...)
+			substituted = true
+			continue
+		}
+		out = append(out, field)
+	}
+	if !substituted {
+		out = append(out, argv...)
+	}
+	return out[0], out[1:], nil
+}
+
+func shellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for idx, arg := range argv {
+		quoted[idx] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(value string) string {
+	if value != "" && strings.Trim(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@,+") == "" {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func appleScriptEscape(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
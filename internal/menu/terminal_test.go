@@ -0,0 +1,100 @@
+package menu
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTerminalLaunch(t *testing.T) {
+	available := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, candidate := range names {
+				if candidate == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+	noEnv := func(string) string { return "" }
+
+	tests := []struct {
+		name     string
+		env      terminalEnv
+		template string
+		keepOpen bool
+		wantCmd  string
+		wantArgs []string
+	}{
+		{
+			name:     "template with placeholder",
+			env:      terminalEnv{goos: "linux", getenv: noEnv, lookPath: available()},
+			template: "alacritty --hold -e {command}",
+			wantCmd:  "alacritty",
+			wantArgs: []string{"--hold", "-e", "htop", "-d", "5"},
+		},
+		{
+			name:     "template without placeholder appends command",
+			env:      terminalEnv{goos: "linux", getenv: noEnv, lookPath: available()},
+			template: "kitty",
+			wantCmd:  "kitty",
+			wantArgs: []string{"htop", "-d", "5"},
+		},
+		{
+			name:     "TERMINAL environment variable",
+			env:      terminalEnv{goos: "linux", getenv: func(string) string { return "foot" }, lookPath: available("foot", "xterm")},
+			wantCmd:  "foot",
+			wantArgs: []string{"-e", "htop", "-d", "5"},
+		},
+		{
+			name:     "gnome-terminal fallback",
+			env:      terminalEnv{goos: "linux", getenv: noEnv, lookPath: available("gnome-terminal", "xterm")},
+			wantCmd:  "gnome-terminal",
+			wantArgs: []string{"--", "htop", "-d", "5"},
+		},
+		{
+			name:     "keep open wraps in shell",
+			env:      terminalEnv{goos: "linux", getenv: noEnv, lookPath: available("xterm")},
+			keepOpen: true,
+			wantCmd:  "xterm",
+			wantArgs: []string{"-e", "sh", "-c", keepOpenScript, "gotray", "htop", "-d", "5"},
+		},
+		{
+			name:     "windows keep open",
+			env:      terminalEnv{goos: "windows", getenv: noEnv, lookPath: available()},
+			keepOpen: true,
+			wantCmd:  "cmd.exe",
+			wantArgs: []string{"/C", "start", "", "cmd.exe", "/K", "htop", "-d", "5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args, err := terminalLaunch(tt.env, tt.template, "htop", []string{"-d", "5"}, tt.keepOpen)
+			if err != nil {
+				t.Fatalf("terminalLaunch returned error: %v", err)
+			}
+			if cmd != tt.wantCmd || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Fatalf("got %s %#v, want %s %#v", cmd, args, tt.wantCmd, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestTerminalLaunchWithoutEmulator(t *testing.T) {
+	env := terminalEnv{
+		goos:     "linux",
+		getenv:   func(string) string { return "" },
+		lookPath: func(string) (string, error) { return "", errors.New("not found") },
+	}
+	if _, _, err := terminalLaunch(env, "", "htop", nil, false); err == nil {
+		t.Fatalf("expected an error when no terminal emulator is available")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellJoin([]string{"ssh", "admin@host", "echo it's"}); got != `ssh admin@host 'echo it'\''s'` {
+		t.Fatalf("unexpected quoting: %s", got)
+	}
+}
@@ -0,0 +1,122 @@
+//go:build cgo || windows
+// +build cgo windows
+
+package menu
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/getlantern/systray"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/runlog"
+)
+
+const maxResultSlots = 10
+
+// resultsMenu renders the most recent command runs as a fixed set of slots
+// because systray cannot remove entries once they have been added.
+type resultsMenu struct {
+	mu      sync.Mutex
+	empty   *systray.MenuItem
+	slots   []*systray.MenuItem
+	records []runlog.Record
+}
+
+func (c *systrayController) addResultsMenu(ctx context.Context, item config.MenuItem, parent *systray.MenuItem) []trayEntry {
+	mi := c.makeMenuItem(parent, item)
+	ctxItem, cancel := context.WithCancel(ctx)
+	go drainClicks(ctxItem, mi.ClickedCh)
+
+	view := &resultsMenu{}
+	view.empty = mi.AddSubMenuItem("No recent runs", "Command output appears here after a command item runs")
+	view.empty.Disable()
+
+	entries := []trayEntry{{item: mi, cancel: cancel}, {item: view.empty, cancel: func() {}}}
+	for idx := 0; idx < maxResultSlots; idx++ {
+		slot := mi.AddSubMenuItem("", "")
+		slot.Hide()
+		view.slots = append(view.slots, slot)
+
+		slotCtx, slotCancel := context.WithCancel(ctx)
+		go func(ch <-chan struct{}, index int) {
+			for {
+				select {
+				case <-slotCtx.Done():
+					return
+				case _, ok := <-ch:
+					if !ok {
+						return
+					}
+					go view.open(index)
+				}
+			}
+		}(slot.ClickedCh, idx)
+		entries = append(entries, trayEntry{item: slot, cancel: slotCancel})
+	}
+
+	c.mu.Lock()
+	c.results = append(c.results, view)
+	c.mu.Unlock()
+
+	view.update()
+	return entries
+}
+
+func (c *systrayController) refreshResults() {
+	c.mu.Lock()
+	menus := make([]*resultsMenu, len(c.results))
+	copy(menus, c.results)
+	c.mu.Unlock()
+
+	for _, view := range menus {
+		view.update()
+	}
+}
+
+func (m *resultsMenu) update() {
+	records, err := runlog.List("", len(m.slots))
+	if err != nil {
+		log.Printf("failed to load recent command runs: %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = records
+	if len(records) == 0 {
+		m.empty.Show()
+	} else {
+		m.empty.Hide()
+	}
+	for idx, slot := range m.slots {
+		if idx >= len(records) {
+			slot.Hide()
+			continue
+		}
+		rec := records[idx]
+		slot.SetTitle(rec.Summary())
+		slot.SetTooltip("Started " + rec.StartedUTC + " — open captured output")
+		slot.Show()
+	}
+}
+
+func (m *resultsMenu) open(index int) {
+	m.mu.Lock()
+	if index < 0 || index >= len(m.records) {
+		m.mu.Unlock()
+		return
+	}
+	rec := m.records[index]
+	m.mu.Unlock()
+
+	path, err := runlog.OutputPath(rec)
+	if err != nil {
+		log.Printf("failed to resolve output for run %s: %v", rec.ID, err)
+		return
+	}
+	openTarget(path)
+}
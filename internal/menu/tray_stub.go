@@ -10,10 +10,14 @@ import (
 
 type trayUnsupported struct{}
 
-func newTrayController(_ func()) trayController {
+func newTrayController(_ func(), _ *supervisor) trayController {
 	return trayUnsupported{}
 }
 
+func (trayUnsupported) SetStatus(TrayStatus) {}
+
+func (trayUnsupported) SetBadge(Badge) {}
+
 func (trayUnsupported) Run(_ context.Context, _ <-chan UpdatePayload) error {
 	return errors.New("system tray is unavailable without cgo support")
 }
@@ -6,23 +6,40 @@ package menu
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/url"
+	"os"
 	"os/exec"
 	"runtime"
-	"sort"
 	"sync"
+	"time"
 
 	"github.com/getlantern/systray"
 
 	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+	"github.com/example/gotray/internal/wol"
 )
 
 type systrayController struct {
-	mu      sync.Mutex
-	entries []trayEntry
-	icon    []byte
-	refresh func()
+	mu         sync.Mutex
+	entries    []trayEntry
+	icon       []byte
+	refresh    func()
+	settings   config.Settings
+	supervisor *supervisor
+	results    []*resultsMenu
+	tasks      []*tasksMenu
+	ready      bool
+	tooltip    string
+	title      string
+	// baseIcon is the configured icon before state variants are applied.
+	baseIcon []byte
+	state    IconState
+	variants iconVariants
+	badge    Badge
 }
 
 type trayEntry struct {
@@ -30,20 +47,30 @@ type trayEntry struct {
 	cancel context.CancelFunc
 }
 
-func newTrayController(refresh func()) trayController {
-	return &systrayController{refresh: refresh}
+func newTrayController(refresh func(), sup *supervisor) trayController {
+	c := &systrayController{refresh: refresh, supervisor: sup}
+	if sup != nil {
+		sup.SetOnChange(c.refreshTasks)
+	}
+	return c
 }
 
 func (c *systrayController) Run(ctx context.Context, updates <-chan UpdatePayload) error {
 	done := make(chan struct{})
 
 	go systray.Run(func() {
-		icon := cloneDefaultIcon()
-		systray.SetIcon(icon)
-		if runtime.GOOS == "darwin" {
-			systray.SetTemplateIcon(icon, icon)
+		c.mu.Lock()
+		c.ready = true
+		tooltip, title := c.tooltip, c.title
+		c.mu.Unlock()
+		if tooltip == "" {
+			tooltip = defaultTooltip
+		}
+		systray.SetTooltip(tooltip)
+		if title != "" {
+			systray.SetTitle(title)
 		}
-		systray.SetTooltip("GoTray")
+		c.updateIcon()
 		go c.listen(ctx, updates)
 	}, func() {
 		c.shutdown()
@@ -60,6 +87,43 @@ func (c *systrayController) Run(ctx context.Context, updates <-chan UpdatePayloa
 	}
 }
 
+// SetStatus updates the tray tooltip, title, and icon state. Values
+// received before the tray is ready are applied once it starts.
+func (c *systrayController) SetStatus(status TrayStatus) {
+	c.mu.Lock()
+	changed := status.Tooltip != c.tooltip || status.Title != c.title
+	stateChanged := status.State != c.state
+	c.tooltip = status.Tooltip
+	c.title = status.Title
+	c.state = status.State
+	ready := c.ready
+	c.mu.Unlock()
+
+	if !ready {
+		return
+	}
+	if changed {
+		systray.SetTooltip(status.Tooltip)
+		systray.SetTitle(status.Title)
+	}
+	if stateChanged {
+		c.updateIcon()
+	}
+}
+
+// SetBadge updates the badge drawn over the tray icon.
+func (c *systrayController) SetBadge(badge Badge) {
+	c.mu.Lock()
+	changed := badge != c.badge
+	c.badge = badge
+	ready := c.ready
+	c.mu.Unlock()
+
+	if ready && changed {
+		c.updateIcon()
+	}
+}
+
 func (c *systrayController) listen(ctx context.Context, updates <-chan UpdatePayload) {
 	for {
 		select {
@@ -71,6 +135,9 @@ func (c *systrayController) listen(ctx context.Context, updates <-chan UpdatePay
 				systray.Quit()
 				return
 			}
+			c.mu.Lock()
+			c.settings = payload.Settings
+			c.mu.Unlock()
 			c.applyIcon(payload.Icon)
 			c.render(ctx, payload.Items)
 		}
@@ -78,7 +145,24 @@ func (c *systrayController) listen(ctx context.Context, updates <-chan UpdatePay
 }
 
 func (c *systrayController) applyIcon(data []byte) {
-	resolved := normalizedIcon(data)
+	c.mu.Lock()
+	c.baseIcon = cloneIcon(data)
+	c.mu.Unlock()
+	c.updateIcon()
+}
+
+// updateIcon shows the variant of the base icon matching the current state
+// with the badge drawn over it.
+func (c *systrayController) updateIcon() {
+	c.mu.Lock()
+	base, state, custom, badge := c.baseIcon, c.state, c.settings.StateIcons, c.badge
+	c.mu.Unlock()
+
+	icon, err := withBadge(c.variants.icon(base, state, custom), badge)
+	if err != nil {
+		logging.Debugf("failed to draw tray badge: %v", err)
+	}
+	resolved := normalizedIcon(icon)
 
 	c.mu.Lock()
 	if bytes.Equal(c.icon, resolved) {
@@ -88,10 +172,13 @@ func (c *systrayController) applyIcon(data []byte) {
 	c.icon = resolved
 	c.mu.Unlock()
 
-	systray.SetIcon(resolved)
-	if runtime.GOOS == "darwin" {
+	// macOS renders template icons in monochrome, which would hide the
+	// coloured state indicators and badges.
+	if runtime.GOOS == "darwin" && (state == "" || state == IconNormal) && !badge.visible() {
 		systray.SetTemplateIcon(resolved, resolved)
+		return
 	}
+	systray.SetIcon(resolved)
 }
 
 func (c *systrayController) render(ctx context.Context, items []config.MenuItem) {
@@ -102,6 +189,8 @@ func (c *systrayController) render(ctx context.Context, items []config.MenuItem)
 	c.mu.Lock()
 	old := c.entries
 	c.entries = nil
+	c.results = nil
+	c.tasks = nil
 	c.mu.Unlock()
 
 	for _, entry := range old {
@@ -137,7 +226,7 @@ func (c *systrayController) addMenuItem(ctx context.Context, item config.MenuIte
 		mi := parent.AddSubMenuItem("—", item.Description)
 		mi.Disable()
 		return []trayEntry{{item: mi, cancel: func() {}}}
-	case config.MenuItemMenu:
+	case config.MenuItemMenu, config.MenuItemGenerator:
 		mi := c.makeMenuItem(parent, item)
 		ctxItem, cancel := context.WithCancel(ctx)
 		go drainClicks(ctxItem, mi.ClickedCh)
@@ -149,10 +238,10 @@ func (c *systrayController) addMenuItem(ctx context.Context, item config.MenuIte
 		ctxItem, cancel := context.WithCancel(ctx)
 		go drainClicks(ctxItem, mi.ClickedCh)
 		return []trayEntry{{item: mi, cancel: cancel}}
-	case config.MenuItemCommand:
+	case config.MenuItemCommand, config.MenuItemWorkflow:
 		mi := c.makeMenuItem(parent, item)
 		ctxItem, cancel := context.WithCancel(ctx)
-		go func(ch <-chan struct{}, cmd string, args []string, dir string) {
+		go func(ch <-chan struct{}, item config.MenuItem) {
 			for {
 				select {
 				case <-ctxItem.Done():
@@ -161,11 +250,49 @@ func (c *systrayController) addMenuItem(ctx context.Context, item config.MenuIte
 					if !ok {
 						return
 					}
-					go executeCommand(ctx, cmd, args, dir)
+					go c.executeCommand(item)
 				}
 			}
-		}(mi.ClickedCh, item.Command, item.Arguments, item.WorkingDir)
+		}(mi.ClickedCh, item)
 		return []trayEntry{{item: mi, cancel: cancel}}
+	case config.MenuItemHTTP:
+		mi := c.makeMenuItem(parent, item)
+		ctxItem, cancel := context.WithCancel(ctx)
+		go func(ch <-chan struct{}, item config.MenuItem) {
+			for {
+				select {
+				case <-ctxItem.Done():
+					return
+				case _, ok := <-ch:
+					if !ok {
+						return
+					}
+					go c.executeRequest(ctx, mi, item)
+				}
+			}
+		}(mi.ClickedCh, item)
+		return []trayEntry{{item: mi, cancel: cancel}}
+	case config.MenuItemWOL:
+		mi := c.makeMenuItem(parent, item)
+		ctxItem, cancel := context.WithCancel(ctx)
+		go func(ch <-chan struct{}, item config.MenuItem) {
+			for {
+				select {
+				case <-ctxItem.Done():
+					return
+				case _, ok := <-ch:
+					if !ok {
+						return
+					}
+					go wakeHost(mi, item)
+				}
+			}
+		}(mi.ClickedCh, item)
+		return []trayEntry{{item: mi, cancel: cancel}}
+	case config.MenuItemResults:
+		return c.addResultsMenu(ctx, item, parent)
+	case config.MenuItemTasks:
+		return c.addTasksMenu(ctx, item, parent)
 	case config.MenuItemQuit:
 		mi := c.makeMenuItem(parent, item)
 		ctxItem, cancel := context.WithCancel(ctx)
@@ -228,10 +355,27 @@ func (c *systrayController) addMenuItem(ctx context.Context, item config.MenuIte
 }
 
 func (c *systrayController) makeMenuItem(parent *systray.MenuItem, item config.MenuItem) *systray.MenuItem {
+	var mi *systray.MenuItem
 	if parent == nil {
-		return systray.AddMenuItem(item.Label, item.Description)
+		mi = systray.AddMenuItem(item.Label, item.Description)
+	} else {
+		mi = parent.AddSubMenuItem(item.Label, item.Description)
+	}
+	if item.Icon != "" {
+		applyItemIcon(mi, item)
+	}
+	return mi
+}
+
+func applyItemIcon(mi *systray.MenuItem, item config.MenuItem) {
+	data, err := os.ReadFile(item.Icon)
+	if err != nil {
+		log.Printf("menu item %s icon unavailable: %v", item.ID, err)
+		return
+	}
+	if icon := platformNormalizeIcon(data); len(icon) > 0 {
+		mi.SetIcon(icon)
 	}
-	return parent.AddSubMenuItem(item.Label, item.Description)
 }
 
 func (c *systrayController) triggerRefresh() {
@@ -256,23 +400,6 @@ func drainClicks(ctx context.Context, ch <-chan struct{}) {
 	}
 }
 
-func groupByParent(items []config.MenuItem) map[string][]config.MenuItem {
-	grouped := make(map[string][]config.MenuItem)
-	for _, item := range items {
-		key := item.ParentID
-		grouped[key] = append(grouped[key], item)
-	}
-	for key := range grouped {
-		sort.SliceStable(grouped[key], func(i, j int) bool {
-			if grouped[key][i].Order == grouped[key][j].Order {
-				return grouped[key][i].ID < grouped[key][j].ID
-			}
-			return grouped[key][i].Order > grouped[key][j].Order
-		})
-	}
-	return grouped
-}
-
 func (c *systrayController) shutdown() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -282,16 +409,50 @@ func (c *systrayController) shutdown() {
 	c.entries = nil
 }
 
-func executeCommand(ctx context.Context, command string, args []string, workingDir string) {
-	if command == "" {
+func (c *systrayController) executeCommand(item config.MenuItem) {
+	if c.supervisor == nil {
 		return
 	}
+	if item.Terminal && item.Type == config.MenuItemCommand {
+		c.mu.Lock()
+		template := c.settings.Terminal
+		c.mu.Unlock()
+
+		wrapped, err := wrapInTerminal(item, template)
+		if err != nil {
+			log.Printf("command item %s cannot open a terminal: %v", item.ID, err)
+			return
+		}
+		item = wrapped
+	}
+	if _, err := c.supervisor.Run(item); err != nil {
+		if errors.Is(err, errAlreadyRunning) {
+			log.Printf("%s item %s is already running; not launching another instance", item.Type, item.ID)
+			return
+		}
+		log.Printf("%s item %s failed to run: %v", item.Type, item.ID, err)
+	}
+	c.refreshResults()
+}
+
+func (c *systrayController) executeRequest(ctx context.Context, mi *systray.MenuItem, item config.MenuItem) {
+	rec := performRequest(ctx, nil, item)
+	status := rec.HTTPStatus
+	if rec.Error != "" && status == "" {
+		status = "failed: " + rec.Error
+		log.Printf("http item %s request failed: %v", item.ID, rec.Error)
+	}
+	mi.SetTooltip(fmt.Sprintf("Last response: %s at %s", status, time.Now().Format(time.Kitchen)))
+	c.refreshResults()
+}
 
-	cmd := exec.CommandContext(ctx, command, args...)
-	if workingDir != "" {
-		cmd.Dir = workingDir
+func wakeHost(mi *systray.MenuItem, item config.MenuItem) {
+	if err := wol.Send(item.MAC, item.Broadcast, item.Port); err != nil {
+		log.Printf("wol item %s failed: %v", item.ID, err)
+		mi.SetTooltip(fmt.Sprintf("Wake-on-LAN failed: %v", err))
+		return
 	}
-	_ = cmd.Start()
+	mi.SetTooltip(fmt.Sprintf("Magic packet sent to %s at %s", item.MAC, time.Now().Format(time.Kitchen)))
 }
 
 func openURL(raw string) {
@@ -301,7 +462,10 @@ func openURL(raw string) {
 	if _, err := url.ParseRequestURI(raw); err != nil {
 		return
 	}
+	openTarget(raw)
+}
 
+func openTarget(raw string) {
 	switch runtime.GOOS {
 	case "windows":
 		_ = exec.Command("rundll32", "url.dll,FileProtocolHandler", raw).Start()
@@ -0,0 +1,122 @@
+//go:build cgo || windows
+// +build cgo windows
+
+package menu
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/getlantern/systray"
+
+	"github.com/example/gotray/internal/config"
+)
+
+const maxTaskSlots = 10
+
+// tasksMenu lists commands still running under the supervisor; selecting an
+// entry stops that command.
+type tasksMenu struct {
+	mu    sync.Mutex
+	empty *systray.MenuItem
+	slots []*systray.MenuItem
+	tasks []TaskInfo
+}
+
+func (c *systrayController) addTasksMenu(ctx context.Context, item config.MenuItem, parent *systray.MenuItem) []trayEntry {
+	mi := c.makeMenuItem(parent, item)
+	ctxItem, cancel := context.WithCancel(ctx)
+	go drainClicks(ctxItem, mi.ClickedCh)
+
+	view := &tasksMenu{}
+	view.empty = mi.AddSubMenuItem("No running tasks", "Commands launched from the tray appear here while they run")
+	view.empty.Disable()
+
+	entries := []trayEntry{{item: mi, cancel: cancel}, {item: view.empty, cancel: func() {}}}
+	for idx := 0; idx < maxTaskSlots; idx++ {
+		slot := mi.AddSubMenuItem("", "")
+		slot.Hide()
+		view.slots = append(view.slots, slot)
+
+		slotCtx, slotCancel := context.WithCancel(ctx)
+		go func(ch <-chan struct{}, index int) {
+			for {
+				select {
+				case <-slotCtx.Done():
+					return
+				case _, ok := <-ch:
+					if !ok {
+						return
+					}
+					go c.stopTaskSlot(view, index)
+				}
+			}
+		}(slot.ClickedCh, idx)
+		entries = append(entries, trayEntry{item: slot, cancel: slotCancel})
+	}
+
+	c.mu.Lock()
+	c.tasks = append(c.tasks, view)
+	c.mu.Unlock()
+
+	view.update(c.runningTasks())
+	return entries
+}
+
+func (c *systrayController) refreshTasks() {
+	c.mu.Lock()
+	menus := make([]*tasksMenu, len(c.tasks))
+	copy(menus, c.tasks)
+	c.mu.Unlock()
+
+	running := c.runningTasks()
+	for _, view := range menus {
+		view.update(running)
+	}
+}
+
+func (c *systrayController) runningTasks() []TaskInfo {
+	if c.supervisor == nil {
+		return nil
+	}
+	return c.supervisor.Running()
+}
+
+func (c *systrayController) stopTaskSlot(view *tasksMenu, index int) {
+	view.mu.Lock()
+	if index < 0 || index >= len(view.tasks) {
+		view.mu.Unlock()
+		return
+	}
+	info := view.tasks[index]
+	view.mu.Unlock()
+
+	if err := c.supervisor.Stop(info.ID); err != nil {
+		log.Printf("failed to stop task for item %s: %v", info.ItemID, err)
+	}
+}
+
+func (m *tasksMenu) update(running []TaskInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tasks = running
+	if len(running) == 0 {
+		m.empty.Show()
+	} else {
+		m.empty.Hide()
+	}
+	for idx, slot := range m.slots {
+		if idx >= len(running) {
+			slot.Hide()
+			continue
+		}
+		info := running[idx]
+		slot.SetTitle(fmt.Sprintf("Stop %s (started %s)", info.Label, info.Started.Format(time.Kitchen)))
+		slot.SetTooltip(fmt.Sprintf("Stop process %d launched by item %s", info.PID, info.ItemID))
+		slot.Show()
+	}
+}
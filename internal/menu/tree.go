@@ -0,0 +1,114 @@
+package menu
+
+import (
+	"github.com/example/gotray/internal/config"
+)
+
+// TreeNode is a menu item as the tray shows it, together with the entries
+// nested below it.
+type TreeNode struct {
+	Item config.MenuItem
+	// Disabled marks entries the tray shows greyed out: dividers inside a
+	// submenu and items of an unsupported type.
+	Disabled bool
+	Children []TreeNode
+}
+
+// BuildTree arranges items the way the tray renders them, with labels and
+// descriptions resolved for the locale tag. Only menu and generator items
+// open a submenu; items the tray cannot reach because their parent is
+// missing or cannot hold children are returned as orphans in input order.
+func BuildTree(items []config.MenuItem, tag string) ([]TreeNode, []config.MenuItem) {
+	localized := localizeItems(items, tag)
+	grouped := groupByParent(localized)
+	shown := make(map[string]bool, len(items))
+
+	var build func(parentID string, nested bool) []TreeNode
+	build = func(parentID string, nested bool) []TreeNode {
+		var nodes []TreeNode
+		for _, item := range grouped[parentID] {
+			if shown[item.ID] {
+				continue
+			}
+			shown[item.ID] = true
+			node := TreeNode{Item: item}
+			switch item.Type {
+			case config.MenuItemDivider:
+				node.Disabled = nested
+			case config.MenuItemMenu, config.MenuItemGenerator:
+				node.Children = build(item.ID, true)
+			default:
+				node.Disabled = !knownItemType(item.Type)
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	nodes := build("", false)
+
+	var orphans []config.MenuItem
+	for _, item := range localized {
+		if !shown[item.ID] {
+			orphans = append(orphans, item)
+		}
+	}
+	return nodes, orphans
+}
+
+// Subtree returns the item with the given id followed by all of its
+// descendants, in input order, or nil when no item has that id.
+func Subtree(items []config.MenuItem, id string) []config.MenuItem {
+	root := findItemByID(items, id)
+	if root == nil {
+		return nil
+	}
+	included := map[string]bool{id: true}
+	subtree := []config.MenuItem{*root}
+	// Repeat until no further descendants are found, since children may be
+	// listed before their parents.
+	for found := true; found; {
+		found = false
+		for _, item := range items {
+			if !included[item.ID] && included[item.ParentID] {
+				included[item.ID] = true
+				subtree = append(subtree, item)
+				found = true
+			}
+		}
+	}
+	return subtree
+}
+
+// RemoveSubtree deletes the item with the given id together with all of its
+// descendants, so no entries are left behind without a parent. It returns
+// the remaining items and the removed ones, in the order of Subtree, or nil
+// removed items when no item has that id.
+func RemoveSubtree(items []config.MenuItem, id string) ([]config.MenuItem, []config.MenuItem) {
+	removed := Subtree(items, id)
+	if removed == nil {
+		return items, nil
+	}
+	ids := make(map[string]bool, len(removed))
+	for _, item := range removed {
+		ids[item.ID] = true
+	}
+	kept := make([]config.MenuItem, 0, len(items)-len(removed))
+	for _, item := range items {
+		if !ids[item.ID] {
+			kept = append(kept, item)
+		}
+	}
+	return kept, removed
+}
+
+func knownItemType(itemType config.MenuItemType) bool {
+	switch itemType {
+	case config.MenuItemText, config.MenuItemDivider, config.MenuItemCommand,
+		config.MenuItemWorkflow, config.MenuItemURL, config.MenuItemHTTP,
+		config.MenuItemWOL, config.MenuItemMenu, config.MenuItemGenerator,
+		config.MenuItemRefresh, config.MenuItemResults, config.MenuItemTasks,
+		config.MenuItemQuit:
+		return true
+	}
+	return false
+}
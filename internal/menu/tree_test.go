@@ -0,0 +1,102 @@
+package menu
+
+import (
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+)
+
+func TestBuildTreeMatchesTrayLayout(t *testing.T) {
+	items := []config.MenuItem{
+		{ID: "10", Order: 10, Type: config.MenuItemText, Label: "Hello", Labels: map[string]string{"de": "Hallo"}},
+		{ID: "20", Order: 20, Type: config.MenuItemGenerator, Label: "Hosts"},
+		{ID: "20.1", Order: 10, ParentID: "20", Type: config.MenuItemCommand, Label: "Ping"},
+		{ID: "20.2", Order: 20, ParentID: "20", Type: config.MenuItemDivider},
+		{ID: "10.1", Order: 10, ParentID: "10", Type: config.MenuItemCommand, Label: "Hidden"},
+		{ID: "30", Order: 30, Type: "bogus", Label: "Old"},
+		{ID: "40", Order: 40, Type: config.MenuItemDivider},
+	}
+
+	nodes, orphans := BuildTree(items, "de")
+	var ids []string
+	for _, node := range nodes {
+		ids = append(ids, node.Item.ID)
+	}
+	if len(ids) != 4 || ids[0] != "40" || ids[1] != "30" || ids[2] != "20" || ids[3] != "10" {
+		t.Fatalf("expected tray order, got %v", ids)
+	}
+	if nodes[0].Disabled || !nodes[1].Disabled {
+		t.Fatalf("expected only the unsupported item to be disabled at the top level")
+	}
+	if nodes[3].Item.Label != "Hallo" {
+		t.Fatalf("expected the localised label, got %q", nodes[3].Item.Label)
+	}
+
+	children := nodes[2].Children
+	if len(children) != 2 || children[0].Item.ID != "20.2" || !children[0].Disabled || children[1].Disabled {
+		t.Fatalf("unexpected submenu %+v", children)
+	}
+	if len(orphans) != 1 || orphans[0].ID != "10.1" {
+		t.Fatalf("expected the child of a text item to be reported as unreachable, got %+v", orphans)
+	}
+}
+
+func TestRepositionAcrossParents(t *testing.T) {
+	items := []config.MenuItem{
+		{ID: "10", Order: 30, Type: config.MenuItemText, Label: "A"},
+		{ID: "20", Order: 20, Type: config.MenuItemGenerator, Label: "Hosts", Command: "hosts"},
+		{ID: "20.10", Order: 10, ParentID: "20", Type: config.MenuItemMenu, Label: "Tools"},
+		{ID: "30", Order: 10, Type: config.MenuItemText, Label: "B"},
+	}
+
+	if err := Reposition(items, "30", "", 0); err != nil {
+		t.Fatalf("reorder: %v", err)
+	}
+	if got := menuIDs(items, ""); got != "30 10 20" {
+		t.Fatalf("expected B first, got %q", got)
+	}
+	if err := Reposition(items, "10", "20", 0); err != nil {
+		t.Fatalf("move into submenu: %v", err)
+	}
+	if got := menuIDs(items, "20"); got != "10 20.10" || menuIDs(items, "") != "30 20" {
+		t.Fatalf("unexpected layout %q / %q", got, menuIDs(items, ""))
+	}
+
+	if err := Reposition(items, "20", "20.10", 0); err == nil {
+		t.Fatalf("expected moving an item into its descendant to fail")
+	}
+	if err := Reposition(items, "20.10", "", 0); err == nil {
+		t.Fatalf("expected a menu item to require a parent")
+	}
+	if err := Reposition(items, "10", "30", 0); err == nil {
+		t.Fatalf("expected a text item to be rejected as parent")
+	}
+	if items[0].ParentID != "20" {
+		t.Fatalf("expected failed moves to leave items untouched, got %+v", items[0])
+	}
+}
+
+func TestRemoveSubtreeDeletesDescendants(t *testing.T) {
+	items := []config.MenuItem{
+		{ID: "20.1.1", ParentID: "20.1", Type: config.MenuItemText, Label: "Deep"},
+		{ID: "10", Type: config.MenuItemText, Label: "Hello"},
+		{ID: "20", Type: config.MenuItemMenu, Label: "Tools"},
+		{ID: "20.1", ParentID: "20", Type: config.MenuItemMenu, Label: "Nested"},
+		{ID: "30", Type: config.MenuItemText, Label: "Bye"},
+	}
+
+	kept, removed := RemoveSubtree(items, "20")
+	if len(kept) != 2 || kept[0].ID != "10" || kept[1].ID != "30" {
+		t.Fatalf("unexpected remaining items %+v", kept)
+	}
+	if len(removed) != 3 || removed[0].ID != "20" {
+		t.Fatalf("expected the item followed by its descendants, got %+v", removed)
+	}
+	if len(items) != 5 || items[0].ID != "20.1.1" {
+		t.Fatalf("expected the input to be left untouched, got %+v", items)
+	}
+
+	if kept, removed := RemoveSubtree(items, "99"); removed != nil || len(kept) != len(items) {
+		t.Fatalf("expected an unknown id to remove nothing")
+	}
+}
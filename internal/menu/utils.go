@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/locale"
 )
 
 // EnsureSequentialOrder assigns deterministic order values for menu items.
@@ -102,6 +103,59 @@ func InsertItem(items []config.MenuItem, index int, item config.MenuItem) []conf
 	return items
 }
 
+// Reposition moves the item with id below parentID, or to the top level when
+// parentID is empty, at the 0-based position among its new siblings in tray
+// order, and renumbers the order values of those siblings. The move is
+// rejected when the item would be invalid at its new place or the parent is
+// the item itself, one of its descendants, or cannot hold other items.
+func Reposition(items []config.MenuItem, id, parentID string, position int) error {
+	idx := findItemIndex(items, id)
+	if idx == -1 {
+		return fmt.Errorf("item with id %s not found", id)
+	}
+
+	moved := items[idx]
+	moved.ParentID = parentID
+	if parentID != "" {
+		for _, descendant := range Subtree(items, id) {
+			if descendant.ID == parentID {
+				return fmt.Errorf("cannot move %s into itself", id)
+			}
+		}
+		parent := findItemByID(items, parentID)
+		if parent == nil {
+			return fmt.Errorf("parent id %s not found", parentID)
+		}
+		if parent.Type != config.MenuItemMenu && parent.Type != config.MenuItemGenerator {
+			return fmt.Errorf("parent %s is a %s item and cannot hold other items", parentID, parent.Type)
+		}
+	}
+	if err := ValidateItem(moved); err != nil {
+		return err
+	}
+	if err := ValidateParent(items, moved); err != nil {
+		return err
+	}
+
+	siblings := make([]config.MenuItem, 0)
+	for _, sibling := range Children(items, parentID) {
+		if sibling.ID != id {
+			siblings = append(siblings, sibling)
+		}
+	}
+	position = min(max(position, 0), len(siblings))
+	siblings = InsertItem(siblings, position, moved)
+
+	items[idx].ParentID = parentID
+	// The tray shows higher order values first.
+	for pos, sibling := range siblings {
+		if i := findItemIndex(items, sibling.ID); i != -1 {
+			items[i].Order = (len(siblings) - pos) * 10
+		}
+	}
+	return nil
+}
+
 // RemoveIndex deletes the element at index when in bounds.
 func RemoveIndex(items []config.MenuItem, index int) []config.MenuItem {
 	if index < 0 || index >= len(items) {
@@ -139,6 +193,31 @@ func ValidateParent(all []config.MenuItem, item config.MenuItem) error {
 	return nil
 }
 
+// groupByParent buckets items by parent id with siblings in the order the
+// tray renders them.
+func groupByParent(items []config.MenuItem) map[string][]config.MenuItem {
+	grouped := make(map[string][]config.MenuItem)
+	for _, item := range items {
+		key := item.ParentID
+		grouped[key] = append(grouped[key], item)
+	}
+	for key := range grouped {
+		sort.SliceStable(grouped[key], func(i, j int) bool {
+			if grouped[key][i].Order == grouped[key][j].Order {
+				return grouped[key][i].ID < grouped[key][j].ID
+			}
+			return grouped[key][i].Order > grouped[key][j].Order
+		})
+	}
+	return grouped
+}
+
+// Children returns the items nested directly below parentID in the order
+// the tray shows them.
+func Children(items []config.MenuItem, parentID string) []config.MenuItem {
+	return groupByParent(items)[parentID]
+}
+
 func filterByParent(items []config.MenuItem, parentID string) []config.MenuItem {
 	out := make([]config.MenuItem, 0)
 	for _, item := range items {
@@ -165,3 +244,15 @@ func lastSegment(id string) string {
 	parts := strings.Split(id, ".")
 	return parts[len(parts)-1]
 }
+
+// localizeItems resolves each item's label and description for the given
+// locale, falling back to the untranslated values.
+func localizeItems(items []config.MenuItem, tag string) []config.MenuItem {
+	out := make([]config.MenuItem, len(items))
+	for idx, item := range items {
+		item.Label = locale.Lookup(item.Labels, tag, item.Label)
+		item.Description = locale.Lookup(item.Descriptions, tag, item.Description)
+		out[idx] = item
+	}
+	return out
+}
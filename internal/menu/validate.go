@@ -0,0 +1,193 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/locale"
+	"github.com/example/gotray/internal/wol"
+)
+
+// ValidateItem checks that an item carries the fields required by its type.
+// Messages refer to the CLI flags that set each field.
+func ValidateItem(item config.MenuItem) error {
+	for _, translations := range []map[string]string{item.Labels, item.Descriptions} {
+		for tag := range translations {
+			if err := locale.Validate(tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch item.Type {
+	case config.MenuItemText:
+		if item.Label == "" {
+			return errors.New("text items require --label")
+		}
+	case config.MenuItemMenu:
+		if item.Label == "" {
+			return errors.New("menu items require --label")
+		}
+		if item.ParentID == "" {
+			return errors.New("menu items require --parent")
+		}
+		if item.Directory != "" && !filepath.IsAbs(item.Directory) {
+			return errors.New("--directory must be an absolute path")
+		}
+	case config.MenuItemCommand:
+		if item.Label == "" {
+			return errors.New("command items require --label")
+		}
+		if item.Command == "" {
+			return errors.New("command items require --command")
+		}
+		if item.TimeoutSeconds < 0 {
+			return errors.New("--timeout cannot be negative")
+		}
+		if item.KeepOpen && !item.Terminal {
+			return errors.New("--keep-open requires --terminal")
+		}
+	case config.MenuItemWorkflow:
+		if item.Label == "" {
+			return errors.New("workflow items require --label")
+		}
+		if len(item.Steps) == 0 {
+			return errors.New("workflow items require --steps")
+		}
+		for idx, step := range item.Steps {
+			if strings.TrimSpace(step.Command) == "" {
+				return fmt.Errorf("workflow step %d requires a command", idx+1)
+			}
+		}
+		if item.TimeoutSeconds < 0 {
+			return errors.New("--timeout cannot be negative")
+		}
+	case config.MenuItemURL:
+		if item.Label == "" {
+			return errors.New("URL items require --label")
+		}
+		if item.URL == "" {
+			return errors.New("URL items require --url")
+		}
+	case config.MenuItemHTTP:
+		if item.Label == "" {
+			return errors.New("http items require --label")
+		}
+		if err := validateRequest(item); err != nil {
+			return err
+		}
+	case config.MenuItemWOL:
+		if item.Label == "" {
+			return errors.New("wol items require --label")
+		}
+		if item.MAC == "" {
+			return errors.New("wol items require --mac")
+		}
+		if _, err := wol.ParseMAC(item.MAC); err != nil {
+			return err
+		}
+		if err := wol.ValidateTarget(item.Broadcast, item.Port); err != nil {
+			return err
+		}
+	case config.MenuItemGenerator:
+		if item.Label == "" {
+			return errors.New("generator items require --label")
+		}
+		if item.Command == "" && item.Source == "" {
+			return errors.New("generator items require --command or --source")
+		}
+		if item.Command != "" && item.Source != "" {
+			return errors.New("generator items accept either --command or --source, not both")
+		}
+		if item.TimeoutSeconds < 0 {
+			return errors.New("--timeout cannot be negative")
+		}
+		if item.IntervalSeconds < 0 {
+			return errors.New("--interval cannot be negative")
+		}
+	case config.MenuItemDivider:
+		if item.Label == "" {
+			return errors.New("divider items require --label")
+		}
+	case config.MenuItemRefresh:
+		if item.Label == "" {
+			return errors.New("refresh items require --label")
+		}
+	case config.MenuItemResults:
+		if item.Label == "" {
+			return errors.New("results items require --label")
+		}
+	case config.MenuItemTasks:
+		if item.Label == "" {
+			return errors.New("tasks items require --label")
+		}
+	case config.MenuItemQuit:
+		if item.Label == "" {
+			return errors.New("quit items require --label")
+		}
+	default:
+		return fmt.Errorf("unsupported menu type: %s", item.Type)
+	}
+	return nil
+}
+
+func validateRequest(item config.MenuItem) error {
+	if item.URL == "" {
+		return errors.New("http items require --url")
+	}
+	if err := ValidateTemplate(item.URL); err != nil {
+		return fmt.Errorf("invalid --url template: %w", err)
+	}
+	if !strings.Contains(item.URL, "{{") {
+		parsed, err := url.Parse(item.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("http items require an http or https --url, got %q", item.URL)
+		}
+	}
+	switch item.Method {
+	case "", http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions:
+	default:
+		return fmt.Errorf("unsupported --method: %s", item.Method)
+	}
+	if err := ValidateTemplate(item.Body); err != nil {
+		return fmt.Errorf("invalid --body template: %w", err)
+	}
+	for name, value := range item.Headers {
+		if err := ValidateTemplate(value); err != nil {
+			return fmt.Errorf("invalid template in header %s: %w", name, err)
+		}
+	}
+	if item.TimeoutSeconds < 0 {
+		return errors.New("--timeout cannot be negative")
+	}
+	return nil
+}
+
+// ValidateBadge checks a badge provider configuration. Messages refer to the
+// settings flags that set each field.
+func ValidateBadge(badge config.BadgeSettings) error {
+	command, target := strings.TrimSpace(badge.Command), strings.TrimSpace(badge.URL)
+	switch {
+	case command != "" && target != "":
+		return errors.New("specify either --badge-command or --badge-url, not both")
+	case command == "" && target == "":
+		return errors.New("badges require --badge-command or --badge-url")
+	case target != "":
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("--badge-url must be an http or https URL, got %q", target)
+		}
+	}
+	if _, err := ParseBadgeColor(badge.Color); err != nil {
+		return err
+	}
+	if badge.IntervalSeconds < 0 {
+		return errors.New("--badge-interval must not be negative")
+	}
+	return nil
+}
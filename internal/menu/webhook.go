@@ -0,0 +1,182 @@
+package menu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+	"github.com/example/gotray/internal/runlog"
+)
+
+const (
+	defaultHTTPTimeout = 15 * time.Second
+	maxResponseBytes   = 1 << 20
+)
+
+// templateData is exposed to http item URL, header, and body templates.
+type templateData struct {
+	ItemID    string
+	Label     string
+	Hostname  string
+	Username  string
+	Timestamp string
+}
+
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+}
+
+// ValidateTemplate reports whether text is a valid http item template.
+func ValidateTemplate(text string) error {
+	_, err := template.New("item").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	return err
+}
+
+func renderTemplate(text string, data templateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("item").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func newTemplateData(item config.MenuItem, now time.Time) templateData {
+	data := templateData{
+		ItemID:    item.ID,
+		Label:     item.Label,
+		Timestamp: now.UTC().Format(time.RFC3339),
+	}
+	if host, err := os.Hostname(); err == nil {
+		data.Hostname = host
+	}
+	if current, err := user.Current(); err == nil {
+		data.Username = current.Username
+	}
+	return data
+}
+
+// performRequest sends the request described by an http item, logs the
+// exchange with sensitive values redacted, and records the outcome in the
+// run log with the response body as output.
+func performRequest(ctx context.Context, client *http.Client, item config.MenuItem) runlog.Record {
+	started := time.Now()
+	rec := runlog.Record{
+		ID:         runlog.NewID(started),
+		ItemID:     item.ID,
+		Label:      item.Label,
+		Command:    strings.ToUpper(httpMethod(item)),
+		Arguments:  []string{item.URL},
+		StartedUTC: started.UTC().Format(time.RFC3339),
+	}
+
+	body, err := sendRequest(ctx, client, item, &rec, started)
+	if err != nil {
+		if rec.HTTPStatus == "" {
+			rec.ExitCode = -1
+		}
+		rec.Error = err.Error()
+	}
+
+	finished := time.Now()
+	rec.FinishedUTC = finished.UTC().Format(time.RFC3339)
+	rec.DurationMS = finished.Sub(started).Milliseconds()
+	if err := runlog.Save(rec, body); err != nil {
+		logging.Debugf("failed to record http item %s result: %v", item.ID, err)
+	}
+	return rec
+}
+
+func sendRequest(ctx context.Context, client *http.Client, item config.MenuItem, rec *runlog.Record, now time.Time) ([]byte, error) {
+	data := newTemplateData(item, now)
+
+	target, err := renderTemplate(item.URL, data)
+	if err != nil {
+		return nil, fmt.Errorf("render url: %w", err)
+	}
+	if _, err := url.ParseRequestURI(target); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	payload, err := renderTemplate(item.Body, data)
+	if err != nil {
+		return nil, fmt.Errorf("render body: %w", err)
+	}
+
+	timeout := defaultHTTPTimeout
+	if item.TimeoutSeconds > 0 {
+		timeout = time.Duration(item.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if payload != "" {
+		reader = strings.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, httpMethod(item), target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range item.Headers {
+		rendered, err := renderTemplate(value, data)
+		if err != nil {
+			return nil, fmt.Errorf("render header %s: %w", name, err)
+		}
+		req.Header.Set(name, rendered)
+	}
+	if payload != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if client == nil {
+		client = &http.Client{}
+	}
+	logging.LogHTTPRequest(req, []byte(payload))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	logging.LogHTTPResponse(resp, body)
+
+	rec.HTTPStatus = resp.Status
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		rec.ExitCode = resp.StatusCode
+		return body, errors.New(resp.Status)
+	}
+	return body, nil
+}
+
+func httpMethod(item config.MenuItem) string {
+	method := strings.ToUpper(strings.TrimSpace(item.Method))
+	if method == "" {
+		if item.Body != "" {
+			return http.MethodPost
+		}
+		return http.MethodGet
+	}
+	return method
+}
@@ -0,0 +1,94 @@
+package menu
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/runlog"
+)
+
+func TestPerformRequestRendersTemplates(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+	t.Setenv("GOTRAY_TEST_TOKEN", "s3cret")
+
+	var gotMethod, gotPath, gotAuth, gotType, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotType = r.Header.Get("Content-Type")
+		raw, _ := io.ReadAll(r.Body)
+		gotBody = string(raw)
+		w.Write([]byte("accepted"))
+	}))
+	defer server.Close()
+
+	rec := performRequest(t.Context(), server.Client(), config.MenuItem{
+		ID:      "10",
+		Type:    config.MenuItemHTTP,
+		Label:   "Notify",
+		URL:     server.URL + "/hooks/{{.ItemID}}",
+		Headers: map[string]string{"Authorization": `Bearer {{env "GOTRAY_TEST_TOKEN"}}`},
+		Body:    `{"label": "{{.Label}}"}`,
+	})
+
+	if !rec.Succeeded() || rec.HTTPStatus != "200 OK" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if gotMethod != http.MethodPost || gotPath != "/hooks/10" {
+		t.Fatalf("unexpected request %s %s", gotMethod, gotPath)
+	}
+	if gotAuth != "Bearer s3cret" || gotType != "application/json" {
+		t.Fatalf("unexpected headers: authorization=%q content-type=%q", gotAuth, gotType)
+	}
+	if gotBody != `{"label": "Notify"}` {
+		t.Fatalf("unexpected body %q", gotBody)
+	}
+
+	output, err := runlog.ReadOutput(rec)
+	if err != nil {
+		t.Fatalf("ReadOutput returned error: %v", err)
+	}
+	if string(output) != "accepted" {
+		t.Fatalf("expected response body in run log, got %q", output)
+	}
+}
+
+func TestPerformRequestRecordsErrorStatus(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	rec := performRequest(t.Context(), server.Client(), config.MenuItem{ID: "10", Label: "Ping", URL: server.URL})
+	if rec.ExitCode != http.StatusNotFound || rec.HTTPStatus != "404 Not Found" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if !strings.Contains(rec.Summary(), "404 Not Found") {
+		t.Fatalf("unexpected summary %q", rec.Summary())
+	}
+}
+
+func TestPerformRequestReportsTemplateErrors(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	rec := performRequest(t.Context(), nil, config.MenuItem{ID: "10", Label: "Broken", URL: "http://localhost/{{.Missing}}"})
+	if rec.ExitCode != -1 || !strings.Contains(rec.Error, "render url") {
+		t.Fatalf("expected template failure, got %+v", rec)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	if err := ValidateTemplate(`{{env "HOME"}} {{.Hostname}}`); err != nil {
+		t.Fatalf("expected valid template, got %v", err)
+	}
+	if err := ValidateTemplate("{{.Label"); err == nil {
+		t.Fatalf("expected an error for an unterminated action")
+	}
+}
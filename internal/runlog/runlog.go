@@ -0,0 +1,485 @@
+package runlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+)
+
+const (
+	runsDirName = "runs"
+
+	// MaxRunsPerItem bounds the rolling history kept for each menu item.
+	MaxRunsPerItem = 20
+
+	// MaxOutputBytes caps the captured output stored for a single run.
+	MaxOutputBytes = 256 << 10
+
+	idLayout = "20060102T150405.000000000Z"
+)
+
+// ErrNotFound is returned when a run identifier does not exist.
+var ErrNotFound = errors.New("run not found")
+
+// Record describes a single command execution captured by the tray.
+type Record struct {
+	ID          string   `json:"id"`
+	ItemID      string   `json:"itemId"`
+	Label       string   `json:"label,omitempty"`
+	Command     string   `json:"command"`
+	Arguments   []string `json:"arguments,omitempty"`
+	WorkingDir  string   `json:"workingDir,omitempty"`
+	StartedUTC  string   `json:"startedUtc"`
+	FinishedUTC string   `json:"finishedUtc"`
+	DurationMS  int64    `json:"durationMs"`
+	ExitCode    int      `json:"exitCode"`
+	Error       string   `json:"error,omitempty"`
+	Truncated   bool     `json:"truncated,omitempty"`
+	// Steps holds per-step outcomes for workflow runs.
+	Steps []StepResult `json:"steps,omitempty"`
+	// HTTPStatus holds the response status line for http item requests.
+	HTTPStatus string `json:"httpStatus,omitempty"`
+}
+
+// StepResult captures the outcome of one workflow step.
+type StepResult struct {
+	Name       string   `json:"name,omitempty"`
+	Command    string   `json:"command"`
+	Arguments  []string `json:"arguments,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+	ExitCode   int      `json:"exitCode"`
+	Error      string   `json:"error,omitempty"`
+	Skipped    bool     `json:"skipped,omitempty"`
+}
+
+// Succeeded reports whether the run exited cleanly.
+func (r Record) Succeeded() bool {
+	return r.Finished() && r.ExitCode == 0 && r.Error == ""
+}
+
+// Finished reports whether the run's outcome was recorded. Runs that are
+// still in progress, or were cut short by GoTray exiting, have none.
+func (r Record) Finished() bool {
+	return r.FinishedUTC != ""
+}
+
+// Duration returns the recorded run time.
+func (r Record) Duration() time.Duration {
+	return time.Duration(r.DurationMS) * time.Millisecond
+}
+
+// Summary renders a short single-line description suitable for menu titles.
+func (r Record) Summary() string {
+	label := r.Label
+	if label == "" {
+		label = r.Command
+	}
+	if !r.Finished() {
+		return fmt.Sprintf("%s — no result yet", label)
+	}
+	if r.Error != "" && r.ExitCode < 0 {
+		return fmt.Sprintf("%s — failed: %s", label, r.Error)
+	}
+	if r.HTTPStatus != "" {
+		return fmt.Sprintf("%s — %s in %s", label, r.HTTPStatus, r.Duration().Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%s — exit %d in %s", label, r.ExitCode, r.Duration().Round(time.Millisecond))
+}
+
+// NewID derives a sortable run identifier from the start time.
+func NewID(started time.Time) string {
+	return started.UTC().Format(idLayout)
+}
+
+var writeMu sync.Mutex
+
+// Dir returns the directory holding captured runs, creating it when needed.
+func Dir() (string, error) {
+	base, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, runsDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("ensure runs directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Save persists the run metadata and captured output, pruning the oldest
+// entries so each item keeps at most MaxRunsPerItem runs.
+func Save(rec Record, output []byte) error {
+	file, err := CreateOutput(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(output); err != nil {
+		file.Close()
+		return fmt.Errorf("write run output: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write run output: %w", err)
+	}
+	rec.Truncated = file.Truncated()
+	return Finish(rec)
+}
+
+// Output streams the output of a run to disk. Writes keep at most twice
+// MaxOutputBytes on disk, dropping the oldest output, and Finish trims the
+// file to MaxOutputBytes.
+type Output struct {
+	mu        sync.Mutex
+	file      *os.File
+	size      int64
+	truncated bool
+}
+
+// CreateOutput opens the output file for a run and records the run as
+// started, so it is listed and pruned even if its outcome is never stored.
+// Call Finish once the run completes to store its metadata.
+func CreateOutput(rec Record) (*Output, error) {
+	if rec.ID == "" {
+		return nil, errors.New("run record is missing an id")
+	}
+
+	dir, err := itemDir(rec.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Appending keeps capOutput safe for processes that write to the file
+	// directly and are still running when their output is trimmed.
+	file, err := os.OpenFile(filepath.Join(dir, rec.ID+".log"), os.O_CREATE|os.O_RDWR|os.O_APPEND|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("create run output: %w", err)
+	}
+
+	writeMu.Lock()
+	err = writeRecord(dir, rec)
+	writeMu.Unlock()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Output{file: file}, nil
+}
+
+// Write appends p, first dropping the oldest output when the file would
+// grow beyond twice MaxOutputBytes.
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.size+int64(len(p)) > 2*MaxOutputBytes {
+		if err := o.compact(len(p)); err != nil {
+			return 0, fmt.Errorf("write run output: %w", err)
+		}
+	}
+	data := p
+	if len(data) > MaxOutputBytes {
+		data = data[len(data)-MaxOutputBytes:]
+		o.truncated = true
+	}
+	n, err := o.file.Write(data)
+	o.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// compact rewrites the file with the most recent output, leaving room for
+// incoming bytes within MaxOutputBytes.
+func (o *Output) compact(incoming int) error {
+	keep := min(max(int64(MaxOutputBytes-incoming), 0), o.size)
+	tail := make([]byte, keep)
+	if _, err := o.file.ReadAt(tail, o.size-keep); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if err := o.file.Truncate(0); err != nil {
+		return err
+	}
+	n, err := o.file.Write(tail)
+	o.size = int64(n)
+	o.truncated = true
+	return err
+}
+
+// File returns the underlying file for processes that must keep writing
+// after GoTray exits and so cannot write through a pipe. Such writes are
+// not capped until Finish.
+func (o *Output) File() *os.File {
+	return o.file
+}
+
+// Truncated reports whether output was dropped to honour the cap.
+func (o *Output) Truncated() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.truncated
+}
+
+// Close closes the output file.
+func (o *Output) Close() error {
+	return o.file.Close()
+}
+
+// Finish records the metadata for a completed run, caps its output to
+// MaxOutputBytes, and prunes the oldest runs for the same item.
+func Finish(rec Record) error {
+	if rec.ID == "" {
+		return errors.New("run record is missing an id")
+	}
+
+	dir, err := itemDir(rec.ItemID)
+	if err != nil {
+		return err
+	}
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	size, truncated, err := capOutput(filepath.Join(dir, rec.ID+".log"))
+	if err != nil {
+		return err
+	}
+	if truncated {
+		rec.Truncated = true
+	}
+
+	if err := writeRecord(dir, rec); err != nil {
+		return err
+	}
+	logging.Debugf("recorded run %s for item %s (exit=%d, %d output bytes)", rec.ID, rec.ItemID, rec.ExitCode, size)
+
+	return prune(dir)
+}
+
+// List returns recorded runs ordered from newest to oldest. When itemID is
+// non-empty only runs for that item are returned. A limit of zero or less
+// returns every run.
+func List(itemID string, limit int) ([]Record, error) {
+	root, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	if itemID != "" {
+		dirs = []string{filepath.Join(root, itemDirName(itemID))}
+	} else {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return nil, fmt.Errorf("read runs directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				dirs = append(dirs, filepath.Join(root, entry.Name()))
+			}
+		}
+	}
+
+	records := make([]Record, 0)
+	for _, dir := range dirs {
+		loaded, err := readDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, loaded...)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ID > records[j].ID
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// Find looks up a run by identifier across all items.
+func Find(id string) (*Record, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrNotFound
+	}
+
+	records, err := List("", 0)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range records {
+		if records[idx].ID == id {
+			return &records[idx], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// OutputPath returns the file holding the captured output for a run.
+func OutputPath(rec Record) (string, error) {
+	root, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, itemDirName(rec.ItemID), rec.ID+".log"), nil
+}
+
+// ReadOutput loads the captured output for a run.
+func ReadOutput(rec Record) ([]byte, error) {
+	path, err := OutputPath(rec)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read run output: %w", err)
+	}
+	return data, nil
+}
+
+func itemDir(itemID string) (string, error) {
+	root, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, itemDirName(itemID))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("ensure item run directory: %w", err)
+	}
+	return dir, nil
+}
+
+func writeRecord(dir string, rec Record) error {
+	meta, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal run record: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, rec.ID+".json"), meta, 0o600); err != nil {
+		return fmt.Errorf("write run record: %w", err)
+	}
+	return nil
+}
+
+// capOutput keeps only the most recent MaxOutputBytes of a run's output.
+func capOutput(path string) (int64, bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("inspect run output: %w", err)
+	}
+	if info.Size() <= MaxOutputBytes {
+		return info.Size(), false, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false, fmt.Errorf("open run output: %w", err)
+	}
+	tail := make([]byte, MaxOutputBytes)
+	_, err = file.ReadAt(tail, info.Size()-MaxOutputBytes)
+	file.Close()
+	if err != nil {
+		return 0, false, fmt.Errorf("read run output: %w", err)
+	}
+	if err := os.WriteFile(path, tail, 0o600); err != nil {
+		return 0, false, fmt.Errorf("truncate run output: %w", err)
+	}
+	return MaxOutputBytes, true, nil
+}
+
+func readDir(dir string) ([]Record, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read run directory: %w", err)
+	}
+
+	records := make([]Record, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			logging.Debugf("skipping unreadable run record %s: %v", entry.Name(), err)
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			logging.Debugf("skipping malformed run record %s: %v", entry.Name(), err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func prune(dir string) error {
+	records, err := readDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(records) <= MaxRunsPerItem {
+		return nil
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ID > records[j].ID
+	})
+	for _, rec := range records[MaxRunsPerItem:] {
+		for _, ext := range []string{".json", ".log"} {
+			if err := os.Remove(filepath.Join(dir, rec.ID+ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("prune run %s: %w", rec.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// itemDirName maps an item ID to a directory name that is safe on every
+// platform. Sanitising alone would let IDs such as "a/b" and "a_b" share a
+// directory, and so each other's history and pruning, so a short hash of
+// the raw ID is appended.
+func itemDirName(itemID string) string {
+	sum := sha256.Sum256([]byte(itemID))
+	suffix := "-" + hex.EncodeToString(sum[:4])
+	trimmed := strings.TrimSpace(itemID)
+	if trimmed == "" {
+		return "_" + suffix
+	}
+	var b strings.Builder
+	for _, r := range trimmed {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if strings.Trim(name, ".") == "" {
+		// Never let an identifier resolve to the current or parent directory.
+		name = "_" + name
+	}
+	return name + suffix
+}
@@ -0,0 +1,152 @@
+package runlog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveKeepsRollingHistoryPerItem(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for idx := 0; idx < MaxRunsPerItem+5; idx++ {
+		rec := Record{
+			ID:       NewID(base.Add(time.Duration(idx) * time.Second)),
+			ItemID:   "10.1",
+			Command:  "echo",
+			ExitCode: idx % 2,
+		}
+		if err := Save(rec, []byte(fmt.Sprintf("run %d\n", idx))); err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+	}
+	if err := Save(Record{ID: NewID(base), ItemID: "20", Command: "true"}, nil); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	records, err := List("10.1", 0)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(records) != MaxRunsPerItem {
+		t.Fatalf("expected %d records, got %d", MaxRunsPerItem, len(records))
+	}
+	newest := NewID(base.Add(time.Duration(MaxRunsPerItem+4) * time.Second))
+	if records[0].ID != newest {
+		t.Fatalf("expected newest run %s first, got %s", newest, records[0].ID)
+	}
+
+	all, err := List("", 3)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected limit to apply, got %d records", len(all))
+	}
+
+	found, err := Find(newest)
+	if err != nil {
+		t.Fatalf("Find returned error: %v", err)
+	}
+	output, err := ReadOutput(*found)
+	if err != nil {
+		t.Fatalf("ReadOutput returned error: %v", err)
+	}
+	if string(output) != fmt.Sprintf("run %d\n", MaxRunsPerItem+4) {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+func TestSaveTruncatesLargeOutput(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	output := make([]byte, MaxOutputBytes+10)
+	output[len(output)-1] = 'x'
+	rec := Record{ID: NewID(time.Now()), ItemID: "10", Command: "yes"}
+	if err := Save(rec, output); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	found, err := Find(rec.ID)
+	if err != nil {
+		t.Fatalf("Find returned error: %v", err)
+	}
+	if !found.Truncated {
+		t.Fatalf("expected record to be marked truncated")
+	}
+	stored, err := ReadOutput(*found)
+	if err != nil {
+		t.Fatalf("ReadOutput returned error: %v", err)
+	}
+	if len(stored) != MaxOutputBytes || stored[len(stored)-1] != 'x' {
+		t.Fatalf("expected the most recent %d bytes to be kept, got %d", MaxOutputBytes, len(stored))
+	}
+}
+
+func TestOutputCapsWhileWriting(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	rec := Record{ID: NewID(time.Now()), ItemID: "10", Command: "yes"}
+	output, err := CreateOutput(rec)
+	if err != nil {
+		t.Fatalf("CreateOutput returned error: %v", err)
+	}
+	started, err := Find(rec.ID)
+	if err != nil {
+		t.Fatalf("expected the run to be recorded when it starts: %v", err)
+	}
+	if started.Finished() || started.Succeeded() {
+		t.Fatalf("expected an unfinished run, got %+v", started)
+	}
+
+	chunk := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	for idx := 0; idx < 5*MaxOutputBytes/len(chunk); idx++ {
+		if _, err := output.Write(chunk); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+		info, err := output.File().Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 2*MaxOutputBytes {
+			t.Fatalf("output grew to %d bytes while running", info.Size())
+		}
+	}
+	output.Write([]byte("end"))
+	output.Close()
+	if !output.Truncated() {
+		t.Fatalf("expected output to be marked truncated")
+	}
+
+	rec.FinishedUTC = time.Now().UTC().Format(time.RFC3339)
+	if err := Finish(rec); err != nil {
+		t.Fatalf("Finish returned error: %v", err)
+	}
+	stored, err := ReadOutput(rec)
+	if err != nil {
+		t.Fatalf("ReadOutput returned error: %v", err)
+	}
+	if len(stored) != MaxOutputBytes || !bytes.HasSuffix(stored, []byte("cdefend")) {
+		t.Fatalf("expected the most recent %d bytes to be kept, got %d", MaxOutputBytes, len(stored))
+	}
+}
+
+func TestItemDirNameSanitises(t *testing.T) {
+	tests := map[string]string{
+		"10.20.1": "10.20.1-",
+		"a/b\\c":  "a_b_c-",
+		"..":      "_..-",
+		"":        "_-",
+	}
+	for input, want := range tests {
+		if got := itemDirName(input); !strings.HasPrefix(got, want) || len(got) != len(want)+8 {
+			t.Fatalf("itemDirName(%q) = %q, want %q followed by a hash", input, got, want)
+		}
+	}
+	if itemDirName("a/b") == itemDirName("a_b") {
+		t.Fatalf("expected IDs that sanitise alike to use different directories")
+	}
+}
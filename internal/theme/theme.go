@@ -0,0 +1,91 @@
+// Package theme detects whether the desktop uses a light or dark colour
+// scheme so the tray can pick a matching icon.
+package theme
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Scheme is a desktop colour scheme.
+type Scheme string
+
+const (
+	Unknown Scheme = ""
+	Light   Scheme = "light"
+	Dark    Scheme = "dark"
+)
+
+// Auto is the setting value that selects automatic detection.
+const Auto = "auto"
+
+// commandTimeout bounds the helper commands used for detection.
+const commandTimeout = 2 * time.Second
+
+// Detect returns the configured scheme when override is "light" or "dark",
+// otherwise the scheme reported by the operating system. Unknown is
+// returned when it cannot be determined.
+func Detect(override string) Scheme {
+	switch Scheme(strings.ToLower(strings.TrimSpace(override))) {
+	case Light:
+		return Light
+	case Dark:
+		return Dark
+	}
+	return systemScheme()
+}
+
+// Validate reports whether value is a valid theme setting: "auto", "light",
+// "dark", or empty.
+func Validate(value string) error {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", Auto, string(Light), string(Dark):
+		return nil
+	}
+	return fmt.Errorf("unknown theme %q; expected auto, light, or dark", value)
+}
+
+var portalValue = regexp.MustCompile(`uint32\s+(\d+)`)
+
+// parsePortalColorScheme interprets the reply of the freedesktop settings
+// portal for org.freedesktop.appearance color-scheme, as printed by gdbus:
+// 1 prefers dark, 2 prefers light, and 0 expresses no preference.
+func parsePortalColorScheme(output string) Scheme {
+	match := portalValue.FindStringSubmatch(output)
+	if match == nil {
+		return Unknown
+	}
+	switch match[1] {
+	case "1":
+		return Dark
+	case "2":
+		return Light
+	}
+	return Unknown
+}
+
+// schemeFromName classifies a GTK theme name or GNOME color-scheme value
+// such as "'Adwaita-dark'" or "'prefer-dark'".
+func schemeFromName(name string) Scheme {
+	name = strings.ToLower(strings.Trim(strings.TrimSpace(name), `'"`))
+	switch {
+	case name == "" || name == "default":
+		return Unknown
+	case strings.Contains(name, "dark"):
+		return Dark
+	case name == "prefer-light":
+		return Light
+	}
+	return Light
+}
+
+func commandOutput(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
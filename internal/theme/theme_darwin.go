@@ -0,0 +1,13 @@
+//go:build darwin
+
+package theme
+
+// systemScheme reads the global AppleInterfaceStyle default, which is only
+// set when dark mode is enabled.
+func systemScheme() Scheme {
+	out, err := commandOutput("defaults", "read", "-g", "AppleInterfaceStyle")
+	if err != nil {
+		return Light
+	}
+	return schemeFromName(out)
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package theme
+
+import "os"
+
+// systemScheme asks the freedesktop settings portal first, then GNOME's
+// color-scheme preference, and finally looks for "dark" in the GTK theme
+// name.
+func systemScheme() Scheme {
+	if out, err := commandOutput("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.portal.Desktop",
+		"--object-path", "/org/freedesktop/portal/desktop",
+		"--method", "org.freedesktop.portal.Settings.Read",
+		"org.freedesktop.appearance", "color-scheme"); err == nil {
+		if scheme := parsePortalColorScheme(out); scheme != Unknown {
+			return scheme
+		}
+	}
+	if out, err := commandOutput("gsettings", "get", "org.gnome.desktop.interface", "color-scheme"); err == nil {
+		if scheme := schemeFromName(out); scheme == Dark {
+			return scheme
+		}
+	}
+	if name := os.Getenv("GTK_THEME"); name != "" {
+		return schemeFromName(name)
+	}
+	if out, err := commandOutput("gsettings", "get", "org.gnome.desktop.interface", "gtk-theme"); err == nil {
+		return schemeFromName(out)
+	}
+	return Unknown
+}
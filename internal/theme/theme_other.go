@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !windows
+
+package theme
+
+func systemScheme() Scheme {
+	return Unknown
+}
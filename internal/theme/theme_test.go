@@ -0,0 +1,42 @@
+package theme
+
+import "testing"
+
+func TestParsePortalColorScheme(t *testing.T) {
+	cases := map[string]Scheme{
+		"(<<uint32 1>>,)": Dark,
+		"(<uint32 2>,)":   Light,
+		"(<<uint32 0>>,)": Unknown,
+		"":                Unknown,
+	}
+	for output, want := range cases {
+		if got := parsePortalColorScheme(output); got != want {
+			t.Fatalf("parsePortalColorScheme(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
+
+func TestSchemeFromName(t *testing.T) {
+	cases := map[string]Scheme{
+		"'Adwaita-dark'": Dark,
+		"Yaru:dark":      Dark,
+		"'prefer-dark'":  Dark,
+		"'Adwaita'":      Light,
+		"Dark":           Dark,
+		"'default'":      Unknown,
+	}
+	for name, want := range cases {
+		if got := schemeFromName(name); got != want {
+			t.Fatalf("schemeFromName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestDetectHonoursOverride(t *testing.T) {
+	if got := Detect(" Dark "); got != Dark {
+		t.Fatalf("expected the override to win, got %q", got)
+	}
+	if err := Validate("sepia"); err == nil {
+		t.Fatalf("expected an unknown theme to be rejected")
+	}
+}
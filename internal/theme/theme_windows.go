@@ -0,0 +1,24 @@
+//go:build windows
+
+package theme
+
+import "golang.org/x/sys/windows/registry"
+
+// systemScheme reads the taskbar theme, which is where the tray icon is
+// shown.
+func systemScheme() Scheme {
+	key, err := registry.OpenKey(registry.CURRENT_USER, `Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`, registry.QUERY_VALUE)
+	if err != nil {
+		return Unknown
+	}
+	defer key.Close()
+
+	light, _, err := key.GetIntegerValue("SystemUsesLightTheme")
+	if err != nil {
+		return Unknown
+	}
+	if light == 0 {
+		return Dark
+	}
+	return Light
+}
@@ -0,0 +1,254 @@
+package trmm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/example/gotray/internal/config"
+	"github.com/example/gotray/internal/logging"
+)
+
+// maxIconSize caps tray icons loaded from a URL or local file.
+const maxIconSize = 4 << 20
+
+// iconCacheDirName is the directory below the state directory holding icons
+// downloaded from URLs.
+const iconCacheDirName = "icon-cache"
+
+// iconCacheEntry records the validator of a cached icon download.
+type iconCacheEntry struct {
+	URL  string `json:"url"`
+	ETag string `json:"etag,omitempty"`
+}
+
+// loadIcon resolves a TrayIcon value: an https:// URL, a file:// URL or
+// absolute path to an existing file, or Base64 data.
+func loadIcon(ctx context.Context, client *http.Client, baseURL, apiKey, value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "https://"):
+		return fetchIcon(ctx, client, baseURL, apiKey, value)
+	case strings.HasPrefix(lower, "http://"):
+		return nil, errors.New("icon URLs must use https")
+	case strings.HasPrefix(lower, "file://"):
+		parsed, err := url.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		path := parsed.Path
+		if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			// file:///C:/icons/tray.png
+			path = path[1:]
+		}
+		return readIconFile(filepath.FromSlash(path))
+	}
+	// Base64 data may itself start with a slash ("/9j/" for JPEG), so only
+	// treat absolute paths that name an existing file as paths.
+	if filepath.IsAbs(value) {
+		if info, err := os.Stat(value); err == nil && info.Mode().IsRegular() {
+			return readIconFile(value)
+		}
+	}
+	return decodeBase64(value)
+}
+
+func readIconFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxIconSize {
+		return nil, fmt.Errorf("icon file %s exceeds 4 MiB", path)
+	}
+	return os.ReadFile(path)
+}
+
+// fetchIcon downloads an icon with the Tactical RMM client and retry policy.
+// Downloads are cached on disk so unchanged icons are revalidated with their
+// ETag and the cached copy is used while the URL cannot be reached.
+func fetchIcon(ctx context.Context, client *http.Client, baseURL, apiKey, iconURL string) ([]byte, error) {
+	cached, entry := readCachedIcon(iconURL)
+
+	data, etag, err := downloadIcon(ctx, client, iconURL, entry.ETag, sameOrigin(baseURL, iconURL), apiKey)
+	switch {
+	case err != nil && cached != nil:
+		logging.Debugf("using cached tray icon for %s: %v", redactQuery(iconURL), err)
+		return cached, nil
+	case err != nil:
+		return nil, err
+	case data == nil:
+		if cached == nil {
+			return nil, errors.New("icon server reported an unchanged icon that is not cached")
+		}
+		logging.Debugf("cached tray icon for %s is current", redactQuery(iconURL))
+		return cached, nil
+	}
+
+	if err := writeCachedIcon(iconURL, etag, data); err != nil {
+		logging.Debugf("failed to cache tray icon: %v", err)
+	}
+	return data, nil
+}
+
+// downloadIcon performs a conditional GET. It returns nil data when the
+// server answers 304 Not Modified. The API key is only sent to the Tactical
+// RMM server itself.
+func downloadIcon(ctx context.Context, client *http.Client, iconURL, etag string, sendKey bool, apiKey string) ([]byte, string, error) {
+	const maxAttempts = 3
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepWithBackoff(ctx, attempt-1); err != nil {
+				return nil, "", err
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, iconURL, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if sendKey {
+			req.Header.Set("X-API-KEY", apiKey)
+		}
+		logging.LogHTTPRequest(req, nil)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if shouldRetryRequest(err) {
+				lastErr = err
+				continue
+			}
+			return nil, "", err
+		}
+		logging.LogHTTPResponse(resp, nil)
+
+		if resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			return nil, etag, nil
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("icon request failed (%d)", resp.StatusCode)
+			if shouldRetryStatus(resp.StatusCode) {
+				continue
+			}
+			return nil, "", lastErr
+		}
+		if resp.ContentLength > maxIconSize {
+			resp.Body.Close()
+			return nil, "", errors.New("icon download exceeds 4 MiB")
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxIconSize+1))
+		resp.Body.Close()
+		if err != nil {
+			if shouldRetryRequest(err) {
+				lastErr = err
+				continue
+			}
+			return nil, "", err
+		}
+		if len(body) > maxIconSize {
+			return nil, "", errors.New("icon download exceeds 4 MiB")
+		}
+		if len(body) == 0 {
+			return nil, "", errors.New("icon download is empty")
+		}
+		return body, resp.Header.Get("ETag"), nil
+	}
+	if lastErr != nil {
+		return nil, "", lastErr
+	}
+	return nil, "", errors.New("icon request failed after retries")
+}
+
+// sameOrigin reports whether target uses the scheme and host of base.
+func sameOrigin(base, target string) bool {
+	baseURL, err := url.Parse(strings.TrimSpace(base))
+	if err != nil || baseURL.Host == "" {
+		return false
+	}
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(baseURL.Scheme, targetURL.Scheme) && strings.EqualFold(baseURL.Host, targetURL.Host)
+}
+
+// redactQuery drops the query string, which may carry access tokens, from a
+// URL before it is logged.
+func redactQuery(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	parsed.RawQuery = ""
+	parsed.User = nil
+	return parsed.String()
+}
+
+func iconCachePaths(iconURL string) (string, string, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(iconURL))
+	name := hex.EncodeToString(sum[:16])
+	base := filepath.Join(dir, iconCacheDirName, name)
+	return base + ".icon", base + ".json", nil
+}
+
+// readCachedIcon returns the cached download of iconURL, or nil when there is
+// none.
+func readCachedIcon(iconURL string) ([]byte, iconCacheEntry) {
+	dataPath, metaPath, err := iconCachePaths(iconURL)
+	if err != nil {
+		return nil, iconCacheEntry{}
+	}
+	meta, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, iconCacheEntry{}
+	}
+	var entry iconCacheEntry
+	if err := json.Unmarshal(meta, &entry); err != nil || entry.URL != iconURL {
+		return nil, iconCacheEntry{}
+	}
+	data, err := os.ReadFile(dataPath)
+	if err != nil || len(data) == 0 {
+		return nil, iconCacheEntry{}
+	}
+	return data, entry
+}
+
+func writeCachedIcon(iconURL, etag string, data []byte) error {
+	dataPath, metaPath, err := iconCachePaths(iconURL)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0o700); err != nil {
+		return fmt.Errorf("ensure icon cache directory: %w", err)
+	}
+	meta, err := json.Marshal(iconCacheEntry{URL: iconURL, ETag: etag})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dataPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, meta, 0o600)
+}
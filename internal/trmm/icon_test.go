@@ -0,0 +1,82 @@
+package trmm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadIconCachesDownloadsByETag(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	icon := []byte("\x89PNG icon data")
+	var requests, revalidated int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-API-KEY") != "" {
+			t.Errorf("expected the API key to stay with the Tactical RMM server")
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidated++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(icon)
+	}))
+	iconURL := server.URL + "/tray.png"
+	client := server.Client()
+	ctx := context.Background()
+
+	for idx := 0; idx < 2; idx++ {
+		got, err := loadIcon(ctx, client, "https://rmm.example.com", "secret", iconURL)
+		if err != nil || !bytes.Equal(got, icon) {
+			t.Fatalf("load %d: got %q, %v", idx, got, err)
+		}
+	}
+	if requests != 2 || revalidated != 1 {
+		t.Fatalf("expected the second load to revalidate the cached copy, got %d requests and %d revalidations", requests, revalidated)
+	}
+
+	server.Close()
+	got, err := loadIcon(ctx, client, "", "", iconURL)
+	if err != nil || !bytes.Equal(got, icon) {
+		t.Fatalf("expected the cached icon while offline, got %q, %v", got, err)
+	}
+}
+
+func TestLoadIconRejectsOversizedDownloads(t *testing.T) {
+	t.Setenv("GOTRAY_STATE_DIR", t.TempDir())
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, maxIconSize+1))
+	}))
+	defer server.Close()
+
+	if _, err := loadIcon(context.Background(), server.Client(), "", "", server.URL); err == nil {
+		t.Fatalf("expected an oversized icon to be rejected")
+	}
+	if _, err := loadIcon(context.Background(), server.Client(), "", "", "http://example.com/icon.png"); err == nil {
+		t.Fatalf("expected a plain http URL to be rejected")
+	}
+}
+
+func TestLoadIconReadsPathsAndBase64(t *testing.T) {
+	icon := []byte("icon bytes")
+	path := filepath.Join(t.TempDir(), "tray.png")
+	if err := os.WriteFile(path, icon, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []string{path, "file://" + filepath.ToSlash(path), base64.StdEncoding.EncodeToString(icon)} {
+		got, err := loadIcon(context.Background(), nil, "", "", value)
+		if err != nil || !bytes.Equal(got, icon) {
+			t.Fatalf("loadIcon(%q) = %q, %v", value, got, err)
+		}
+	}
+}
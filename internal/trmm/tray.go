@@ -22,6 +22,14 @@ import (
 type TrayData struct {
 	MenuItems []config.MenuItem
 	Icon      []byte
+	// IconDark replaces Icon when the desktop uses a dark colour scheme.
+	IconDark []byte
+	// Tooltip and Title are templates that override the tray tooltip and
+	// title configured locally.
+	Tooltip string
+	Title   string
+	// AgentName is the agent's hostname as recorded in Tactical RMM.
+	AgentName string
 }
 
 var errNotFound = errors.New("tacticalrmm: not found")
@@ -116,6 +124,7 @@ func (v customFieldValue) ValueString() string {
 
 type agentDetails struct {
 	AgentID      string             `json:"agent_id"`
+	Hostname     string             `json:"hostname"`
 	SiteID       int                `json:"site"`
 	CustomFields []customFieldValue `json:"custom_fields"`
 }
@@ -231,27 +240,39 @@ func FetchTrayData(ctx context.Context, httpClient *http.Client, opts Options) (
 		}
 	}
 
-	iconValue := firstNonEmpty(
-		lookupInsensitive(globalKeys, "TrayIcon"),
-		extractFieldValue(agent.CustomFields, findDefinition(defs, "agent", "TrayIcon")),
-		extractFieldValue(siteFields(site), findDefinition(defs, "site", "TrayIcon")),
-		extractFieldValue(clientFields(client), findDefinition(defs, "client", "TrayIcon")),
-		defaultValue(findDefinition(defs, "agent", "TrayIcon")),
-		defaultValue(findDefinition(defs, "site", "TrayIcon")),
-		defaultValue(findDefinition(defs, "client", "TrayIcon")),
-	)
+	// lookupValue resolves a single-valued setting, preferring the global key
+	// store, then the most specific custom field, then field defaults.
+	lookupValue := func(name string) string {
+		return firstNonEmpty(
+			lookupInsensitive(globalKeys, name),
+			extractFieldValue(agent.CustomFields, findDefinition(defs, "agent", name)),
+			extractFieldValue(siteFields(site), findDefinition(defs, "site", name)),
+			extractFieldValue(clientFields(client), findDefinition(defs, "client", name)),
+			defaultValue(findDefinition(defs, "agent", name)),
+			defaultValue(findDefinition(defs, "site", name)),
+			defaultValue(findDefinition(defs, "client", name)),
+		)
+	}
 
-	var iconData []byte
-	if iconValue != "" {
-		decoded, err := decodeBase64(iconValue)
+	tooltip := lookupValue("TrayTooltip")
+	title := lookupValue("TrayTitle")
+
+	decodeIcon := func(name string) []byte {
+		value := lookupValue(name)
+		if value == "" {
+			return nil
+		}
+		decoded, err := loadIcon(ctx, httpClient, baseURL, apiKey, value)
 		if err != nil {
-			warnings.add(fmt.Errorf("decode tray icon: %w", err))
-			logging.Debugf("failed to decode Tactical RMM icon payload: %v", err)
-		} else {
-			iconData = decoded
-			logging.Debugf("decoded Tactical RMM icon payload (%d bytes)", len(iconData))
+			warnings.add(fmt.Errorf("load %s: %w", name, err))
+			logging.Debugf("failed to load Tactical RMM %s: %v", name, err)
+			return nil
 		}
+		logging.Debugf("loaded Tactical RMM %s (%d bytes)", name, len(decoded))
+		return decoded
 	}
+	iconData := decodeIcon("TrayIcon")
+	iconDark := decodeIcon("TrayIconDark")
 
 	var menuItems []config.MenuItem
 	appendMenu := func(payload, source string) bool {
@@ -293,14 +314,21 @@ func FetchTrayData(ctx context.Context, httpClient *http.Client, opts Options) (
 		appendMenu(defaultValue(findDefinition(defs, "agent", "TrayMenu")), "agent default")
 	}
 
-	if len(menuItems) == 0 && len(iconData) == 0 {
+	if len(menuItems) == 0 && len(iconData) == 0 && len(iconDark) == 0 && tooltip == "" && title == "" {
 		if warnErr := warnings.err(); warnErr != nil {
 			return nil, warnErr
 		}
 		return nil, nil
 	}
 
-	data := &TrayData{MenuItems: menuItems, Icon: iconData}
+	data := &TrayData{
+		MenuItems: menuItems,
+		Icon:      iconData,
+		IconDark:  iconDark,
+		Tooltip:   tooltip,
+		Title:     title,
+		AgentName: strings.TrimSpace(agent.Hostname),
+	}
 	return data, warnings.err()
 }
 
@@ -78,3 +78,13 @@ func TestParseMenuUnsupported(t *testing.T) {
 		t.Fatalf("expected error for unsupported payload")
 	}
 }
+
+func TestParseMenuKeepsTranslations(t *testing.T) {
+	items, err := parseMenu(`[{"id": "10", "type": "quit", "label": "Quit", "labels": {"de": "Beenden", "fr": "Quitter"}, "descriptions": {"de": "GoTray beenden"}}]`)
+	if err != nil {
+		t.Fatalf("parseMenu returned error: %v", err)
+	}
+	if len(items) != 1 || items[0].Labels["de"] != "Beenden" || items[0].Descriptions["de"] != "GoTray beenden" {
+		t.Fatalf("translations were not decoded: %+v", items)
+	}
+}
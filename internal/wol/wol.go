@@ -0,0 +1,103 @@
+package wol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultBroadcast is used when an item does not specify a broadcast address.
+	DefaultBroadcast = "255.255.255.255"
+
+	// DefaultPort is the discard port conventionally used for magic packets.
+	DefaultPort = 9
+)
+
+// ParseMAC validates a 48-bit MAC address written with colon, hyphen, or
+// dot separators, or as twelve bare hexadecimal digits.
+func ParseMAC(raw string) (net.HardwareAddr, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return nil, errors.New("mac address is empty")
+	}
+	if len(trimmed) == 12 && !strings.ContainsAny(trimmed, ":-.") {
+		var b strings.Builder
+		for idx := 0; idx < len(trimmed); idx += 2 {
+			if idx > 0 {
+				b.WriteByte(':')
+			}
+			b.WriteString(trimmed[idx : idx+2])
+		}
+		trimmed = b.String()
+	}
+	addr, err := net.ParseMAC(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac address %q", raw)
+	}
+	if len(addr) != 6 {
+		return nil, fmt.Errorf("invalid mac address %q: expected 6 bytes", raw)
+	}
+	return addr, nil
+}
+
+// MagicPacket builds the 102-byte payload that wakes the given interface:
+// six 0xFF bytes followed by sixteen repetitions of the MAC address.
+func MagicPacket(addr net.HardwareAddr) []byte {
+	packet := bytes.Repeat([]byte{0xFF}, 6)
+	for range 16 {
+		packet = append(packet, addr...)
+	}
+	return packet
+}
+
+// Send broadcasts a magic packet for mac to the given address and UDP port.
+// An empty broadcast address or a zero port fall back to the defaults.
+func Send(mac, broadcast string, port int) error {
+	addr, err := ParseMAC(mac)
+	if err != nil {
+		return err
+	}
+	target, err := resolveTarget(broadcast, port)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialUDP("udp4", nil, target)
+	if err != nil {
+		return fmt.Errorf("open udp socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(MagicPacket(addr)); err != nil {
+		return fmt.Errorf("send magic packet to %s: %w", target, err)
+	}
+	return nil
+}
+
+// ValidateTarget checks a broadcast address and port without sending anything.
+func ValidateTarget(broadcast string, port int) error {
+	_, err := resolveTarget(broadcast, port)
+	return err
+}
+
+func resolveTarget(broadcast string, port int) (*net.UDPAddr, error) {
+	host := strings.TrimSpace(broadcast)
+	if host == "" {
+		host = DefaultBroadcast
+	}
+	if port == 0 {
+		port = DefaultPort
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("invalid broadcast address %q: expected an IPv4 address", broadcast)
+	}
+	return net.ResolveUDPAddr("udp4", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+}
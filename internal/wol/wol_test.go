@@ -0,0 +1,74 @@
+package wol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseMAC(t *testing.T) {
+	for _, raw := range []string{"00:11:22:aa:bb:cc", "00-11-22-AA-BB-CC", "0011.22aa.bbcc", "001122aabbcc"} {
+		addr, err := ParseMAC(raw)
+		if err != nil {
+			t.Fatalf("ParseMAC(%q) returned error: %v", raw, err)
+		}
+		if addr.String() != "00:11:22:aa:bb:cc" {
+			t.Fatalf("ParseMAC(%q) = %s", raw, addr)
+		}
+	}
+	for _, raw := range []string{"", "00:11:22:aa:bb", "zz:11:22:aa:bb:cc", "00:00:5e:10:00:00:00:01"} {
+		if _, err := ParseMAC(raw); err == nil {
+			t.Fatalf("expected ParseMAC(%q) to fail", raw)
+		}
+	}
+}
+
+func TestMagicPacket(t *testing.T) {
+	addr, _ := ParseMAC("01:02:03:04:05:06")
+	packet := MagicPacket(addr)
+	if len(packet) != 102 {
+		t.Fatalf("expected 102 bytes, got %d", len(packet))
+	}
+	if !bytes.Equal(packet[:6], bytes.Repeat([]byte{0xFF}, 6)) {
+		t.Fatalf("missing sync stream: %x", packet[:6])
+	}
+	if !bytes.Equal(packet[96:], []byte(addr)) {
+		t.Fatalf("unexpected trailing repetition: %x", packet[96:])
+	}
+}
+
+func TestSendDeliversPacket(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("udp unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	if err := Send("aa:bb:cc:dd:ee:ff", "127.0.0.1", port); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("no packet received: %v", err)
+	}
+	if n != 102 || buf[6] != 0xAA {
+		t.Fatalf("unexpected packet %x", buf[:n])
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	if err := ValidateTarget("", 0); err != nil {
+		t.Fatalf("defaults should be valid: %v", err)
+	}
+	if err := ValidateTarget("lab.example.com", 9); err == nil {
+		t.Fatalf("expected hostnames to be rejected")
+	}
+	if err := ValidateTarget("192.168.1.255", 70000); err == nil {
+		t.Fatalf("expected out of range port to be rejected")
+	}
+}